@@ -26,24 +26,39 @@ import (
 const (
 	MinPacketSize = 3
 	TagValue      = 0x05
+
+	// Channel and PacketSize used by Ledger devices over HID
+	Channel    = 0x0101
+	PacketSize = 64
 )
 
 var codec = binary.BigEndian
 
 const (
-	ErrMsgPacketSize       = "packet size must be at least 3"
-	ErrMsgInvalidChannel   = "invalid channel"
-	ErrMsgInvalidTag       = "invalid tag"
-	ErrMsgWrongSequenceIdx = "wrong sequenceIdx"
+	ErrMsgPacketSize            = "packet size must be at least 3"
+	ErrMsgInvalidChannel        = "invalid channel"
+	ErrMsgInvalidTag            = "invalid tag"
+	ErrMsgWrongSequenceIdx      = "wrong sequenceIdx"
+	ErrMsgCommandTooShort       = "APDU commands should not be smaller than 5"
+	ErrMsgCommandLengthMismatch = "APDU[data length] mismatch"
+	ErrMsgResponseTooShort      = "len(response) < 2"
+	ErrMsgIncompleteResponse    = "incomplete response"
 )
 
 var (
-	ErrPacketSize       = errors.New(ErrMsgPacketSize)
-	ErrInvalidChannel   = errors.New(ErrMsgInvalidChannel)
-	ErrInvalidTag       = errors.New(ErrMsgInvalidTag)
-	ErrWrongSequenceIdx = errors.New(ErrMsgWrongSequenceIdx)
+	ErrPacketSize            = errors.New(ErrMsgPacketSize)
+	ErrInvalidChannel        = errors.New(ErrMsgInvalidChannel)
+	ErrInvalidTag            = errors.New(ErrMsgInvalidTag)
+	ErrWrongSequenceIdx      = errors.New(ErrMsgWrongSequenceIdx)
+	ErrCommandTooShort       = errors.New(ErrMsgCommandTooShort)
+	ErrCommandLengthMismatch = errors.New(ErrMsgCommandLengthMismatch)
+	ErrResponseTooShort      = errors.New(ErrMsgResponseTooShort)
+	ErrIncompleteResponse    = errors.New(ErrMsgIncompleteResponse)
 )
 
+// SwOK is the status word of a successful command
+const SwOK = 0x9000
+
 // ErrorMessage returns a human-readable error message for a given APDU error code.
 func ErrorMessage(errorCode uint16) string {
 	switch errorCode {
@@ -83,6 +98,56 @@ func ErrorMessage(errorCode uint16) string {
 	}
 }
 
+// APDUError is returned when the device answers with a status word other than 0x9000.
+type APDUError struct {
+	Code uint16
+}
+
+func (e *APDUError) Error() string {
+	return ErrorMessage(e.Code)
+}
+
+// StatusWord extracts the status word from an error returned by Exchange.
+func StatusWord(err error) (uint16, bool) {
+	var apduErr *APDUError
+	if errors.As(err, &apduErr) {
+		return apduErr.Code, true
+	}
+	return 0, false
+}
+
+// ValidateCommand checks that a command has a complete header and that
+// its data length byte matches the data sent.
+func ValidateCommand(command []byte) error {
+	if len(command) < 5 {
+		return ErrCommandTooShort
+	}
+
+	if (byte)(len(command)-5) != command[4] {
+		return ErrCommandLengthMismatch
+	}
+
+	return nil
+}
+
+// ParseResponse removes the trailing status word from a raw device reply.
+// Any status word other than SwOK is returned as an *APDUError, together with
+// the data sent before it.
+func ParseResponse(response []byte) ([]byte, error) {
+	if len(response) < 2 {
+		return nil, ErrResponseTooShort
+	}
+
+	swOffset := len(response) - 2
+	sw := codec.Uint16(response[swOffset:])
+
+	if sw != SwOK {
+		return response[:swOffset], &APDUError{Code: sw}
+	}
+
+	return response[:swOffset], nil
+}
+
 // SerializePacket serializes a command into a packet for transmission.
 func SerializePacket(
 	channel uint16,
@@ -221,6 +286,11 @@ func UnwrapResponseAPDU(channel uint16, pipe <-chan []byte, packetSize int) ([]b
 		}
 	}
 
+	// The pipe was closed before the whole response arrived
+	if !foundZeroSequence || len(totalResult) < int(totalSize) {
+		return nil, fmt.Errorf("%w: received %d of %d bytes", ErrIncompleteResponse, len(totalResult), totalSize)
+	}
+
 	// Remove trailing zeros
 	return totalResult[:totalSize], nil
 }
@@ -296,3 +296,27 @@ func TestSerializeDeserialize(t *testing.T) {
 
 	assert.True(t, bytes.Equal(output[:len(sampleCommand)], sampleCommand), "Deserialized message does not match the original")
 }
+
+func TestValidateCommand(t *testing.T) {
+	assert.NoError(t, ValidateCommand([]byte{0xE0, 0x01, 0x00, 0x00, 0x00}))
+	assert.NoError(t, ValidateCommand([]byte{0xE0, 0x01, 0x00, 0x00, 0x02, 0xAA, 0xBB}))
+	assert.ErrorIs(t, ValidateCommand([]byte{0xE0, 0x01}), ErrCommandTooShort)
+	assert.ErrorIs(t, ValidateCommand([]byte{0xE0, 0x01, 0x00, 0x00, 0x02, 0xAA}), ErrCommandLengthMismatch)
+}
+
+func TestParseResponse(t *testing.T) {
+	data, err := ParseResponse([]byte{0x01, 0x02, 0x90, 0x00})
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{0x01, 0x02}, data)
+
+	data, err = ParseResponse([]byte{0x01, 0x69, 0x85})
+	assert.EqualError(t, err, ErrorMessage(0x6985))
+	assert.Equal(t, []byte{0x01}, data)
+
+	sw, ok := StatusWord(err)
+	assert.True(t, ok)
+	assert.Equal(t, uint16(0x6985), sw)
+
+	_, err = ParseResponse([]byte{0x90})
+	assert.ErrorIs(t, err, ErrResponseTooShort)
+}
@@ -0,0 +1,328 @@
+/*******************************************************************************
+*   (c) Zondax AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+********************************************************************************/
+
+package ledger_go
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/zondax/ledger-go/bip32"
+)
+
+// Instructions shared by Zondax apps
+const (
+	InsGetVersion = 0x00
+	InsGetAddr    = 0x01
+	InsSign       = 0x02
+)
+
+// Payload descriptors sent in P1 while signing
+const (
+	PayloadInit = 0x00
+	PayloadAdd  = 0x01
+	PayloadLast = 0x02
+)
+
+const ErrMsgEmptyMessage = "empty message"
+
+var ErrEmptyMessage = errors.New(ErrMsgEmptyMessage)
+
+const (
+	P1GetAddrSilent = 0x00
+	P1GetAddrShow   = 0x01
+
+	DefaultChunkSize = 250
+	// MaxChunkSize is the largest payload a short APDU can carry, its length being a single byte
+	MaxChunkSize = 0xFF
+)
+
+// AppConfig describes the parts of the APDU layout that differ between Zondax apps.
+// Zero values select the layout shared by most apps.
+type AppConfig struct {
+	CLA byte
+
+	// Instruction overrides, InsGetAddr and InsSign are used when zero
+	InsGetAddr byte
+	InsSign    byte
+
+	// PubKeyLength is the size of the public key preceding the address in GET_ADDR replies.
+	// When zero, the public key is expected to be prefixed by its length.
+	PubKeyLength int
+
+	// SerializePath encodes a derivation path. Defaults to SerializePathLE.
+	SerializePath func(path []uint32) ([]byte, error)
+
+	// ChunkSize is the maximum payload per SIGN chunk. Defaults to DefaultChunkSize,
+	// larger values are clamped to MaxChunkSize.
+	ChunkSize int
+}
+
+// VersionInfo is the reply to GET_VERSION.
+type VersionInfo struct {
+	AppMode  uint8
+	Major    uint16
+	Minor    uint16
+	Patch    uint16
+	Locked   bool
+	TargetID uint32
+}
+
+func (v VersionInfo) String() string {
+	return fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+}
+
+// AddressInfo is the reply to GET_ADDR.
+type AddressInfo struct {
+	PubKey  []byte
+	Address string
+}
+
+// AppClient talks to any app following the Zondax APDU layout.
+type AppClient struct {
+	device LedgerDevice
+	config AppConfig
+}
+
+// NewAppClient returns a client for the app selected by config.CLA.
+func NewAppClient(device LedgerDevice, config AppConfig) *AppClient {
+	if config.InsGetAddr == 0 {
+		config.InsGetAddr = InsGetAddr
+	}
+	if config.InsSign == 0 {
+		config.InsSign = InsSign
+	}
+	if config.SerializePath == nil {
+		config.SerializePath = SerializePathLE
+	}
+	if config.ChunkSize <= 0 {
+		config.ChunkSize = DefaultChunkSize
+	}
+	if config.ChunkSize > MaxChunkSize {
+		config.ChunkSize = MaxChunkSize
+	}
+
+	return &AppClient{device: device, config: config}
+}
+
+// Device returns the underlying device.
+func (c *AppClient) Device() LedgerDevice {
+	return c.device
+}
+
+// GetVersion returns the version of the app.
+func (c *AppClient) GetVersion() (*VersionInfo, error) {
+	response, err := c.device.Exchange([]byte{c.config.CLA, InsGetVersion, 0, 0, 0})
+	if err != nil {
+		return nil, appError(response, err)
+	}
+
+	return parseVersionInfo(response)
+}
+
+func parseVersionInfo(response []byte) (*VersionInfo, error) {
+	// Newer apps use two bytes per version component
+	const longFormatSize = 12
+
+	if len(response) >= longFormatSize {
+		return &VersionInfo{
+			AppMode:  response[0],
+			Major:    codec.Uint16(response[1:]),
+			Minor:    codec.Uint16(response[3:]),
+			Patch:    codec.Uint16(response[5:]),
+			Locked:   response[7] == 1,
+			TargetID: codec.Uint32(response[8:]),
+		}, nil
+	}
+
+	if len(response) < 4 {
+		return nil, fmt.Errorf("%w: version reply too short (%d bytes)", ErrInvalidResponse, len(response))
+	}
+
+	version := &VersionInfo{
+		AppMode: response[0],
+		Major:   uint16(response[1]),
+		Minor:   uint16(response[2]),
+		Patch:   uint16(response[3]),
+	}
+
+	// Locked and target id are missing in old apps
+	if len(response) >= 5 {
+		version.Locked = response[4] == 1
+	}
+	if len(response) >= 9 {
+		version.TargetID = codec.Uint32(response[5:])
+	}
+
+	return version, nil
+}
+
+// GetAddress returns the public key and address for path, displaying it on the device if show is set.
+// Showing the address is an interactive exchange, see ExchangeInteractive.
+func (c *AppClient) GetAddress(path []uint32, show bool) (*AddressInfo, error) {
+	return c.GetAddressWithData(path, show, nil)
+}
+
+// GetAddressWithData is like GetAddress, appending app specific data (e.g. an HRP) after the path.
+func (c *AppClient) GetAddressWithData(path []uint32, show bool, data []byte) (*AddressInfo, error) {
+	pathBytes, err := c.config.SerializePath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	payload := append(pathBytes, data...)
+	if len(payload) > MaxChunkSize {
+		return nil, fmt.Errorf("address request too long (%d bytes)", len(payload))
+	}
+
+	p1 := byte(P1GetAddrSilent)
+	if show {
+		p1 = P1GetAddrShow
+	}
+
+	command := append([]byte{c.config.CLA, c.config.InsGetAddr, p1, 0, byte(len(payload))}, payload...)
+
+	exchange := c.device.Exchange
+	if show {
+		exchange = c.exchangeInteractive
+	}
+
+	response, err := exchange(command)
+	if err != nil {
+		return nil, appError(response, err)
+	}
+
+	return c.parseAddress(response)
+}
+
+func (c *AppClient) parseAddress(response []byte) (*AddressInfo, error) {
+	pubKeyLength := c.config.PubKeyLength
+	offset := 0
+
+	if pubKeyLength == 0 {
+		if len(response) < 1 {
+			return nil, fmt.Errorf("%w: empty address reply", ErrInvalidResponse)
+		}
+		pubKeyLength = int(response[0])
+		offset = 1
+	}
+
+	if len(response) < offset+pubKeyLength {
+		return nil, fmt.Errorf("%w: address reply too short (%d bytes)", ErrInvalidResponse, len(response))
+	}
+
+	return &AddressInfo{
+		PubKey:  response[offset : offset+pubKeyLength],
+		Address: string(response[offset+pubKeyLength:]),
+	}, nil
+}
+
+// Sign sends message in chunks to be signed with the key at path and returns the signature.
+// The last chunk is sent as an interactive exchange, so a rejection is reported as ErrUserRefused.
+func (c *AppClient) Sign(path []uint32, message []byte) ([]byte, error) {
+	return c.SignP2(path, message, 0)
+}
+
+// SignP2 is like Sign, sending p2 in every chunk for apps that use it to select the signing mode.
+// The path goes in the PayloadInit chunk, so the message must not be empty.
+func (c *AppClient) SignP2(path []uint32, message []byte, p2 byte) ([]byte, error) {
+	if len(message) == 0 {
+		return nil, ErrEmptyMessage
+	}
+
+	pathBytes, err := c.config.SerializePath(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(pathBytes) > MaxChunkSize {
+		return nil, fmt.Errorf("path too long (%d bytes)", len(pathBytes))
+	}
+
+	chunks := PrepareChunks(pathBytes, message, c.config.ChunkSize)
+
+	var response []byte
+	for i, chunk := range chunks {
+		p1 := byte(PayloadAdd)
+		switch {
+		case i == 0:
+			p1 = PayloadInit
+		case i == len(chunks)-1:
+			p1 = PayloadLast
+		}
+
+		command := append([]byte{c.config.CLA, c.config.InsSign, p1, p2, byte(len(chunk))}, chunk...)
+
+		// The user is asked to review the message once the last chunk is received
+		exchange := c.device.Exchange
+		if p1 == PayloadLast {
+			exchange = c.exchangeInteractive
+		}
+
+		response, err = exchange(command)
+		if err != nil {
+			return nil, appError(response, err)
+		}
+	}
+
+	return response, nil
+}
+
+func (c *AppClient) exchangeInteractive(command []byte) ([]byte, error) {
+	return ExchangeInteractive(c.device, command)
+}
+
+// PrepareChunks splits message into chunks of at most chunkSize bytes, preceded by a chunk with the path.
+func PrepareChunks(pathBytes []byte, message []byte, chunkSize int) [][]byte {
+	chunks := [][]byte{pathBytes}
+
+	for len(message) > 0 {
+		size := chunkSize
+		if len(message) < size {
+			size = len(message)
+		}
+		chunks = append(chunks, message[:size])
+		message = message[size:]
+	}
+
+	return chunks
+}
+
+// SerializePathLE encodes each path component as a little endian uint32, the layout used by most Zondax apps.
+func SerializePathLE(path []uint32) ([]byte, error) {
+	if len(path) == 0 {
+		return nil, fmt.Errorf("%w: empty path", bip32.ErrInvalidPath)
+	}
+	if len(path) > bip32.MaxDepth {
+		return nil, fmt.Errorf("%w: more than %d components", bip32.ErrInvalidPath, bip32.MaxDepth)
+	}
+
+	return bip32.Path(path).BytesLE(), nil
+}
+
+// appError adds the error description sent by Zondax apps along with some status words.
+func appError(response []byte, err error) error {
+	sw, ok := StatusWord(err)
+	if !ok || len(response) == 0 {
+		return err
+	}
+
+	switch sw {
+	case 0x6984, 0x6A80:
+		return fmt.Errorf("%w: %s", err, string(response))
+	default:
+		return err
+	}
+}
@@ -0,0 +1,206 @@
+/*******************************************************************************
+*   (c) Zondax AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+********************************************************************************/
+
+package ledger_go
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testPath = []uint32{44 | 0x80000000, 118 | 0x80000000, 0x80000000, 0, 0}
+
+func TestAppClientGetVersion(t *testing.T) {
+	tests := []struct {
+		name     string
+		reply    string
+		expected VersionInfo
+	}{
+		{"Legacy", "00020304", VersionInfo{Major: 2, Minor: 3, Patch: 4}},
+		{"Short", "ff0203040133000004", VersionInfo{AppMode: 0xff, Major: 2, Minor: 3, Patch: 4, Locked: true, TargetID: 0x33000004}},
+		{"Long", "000002002200050031100004", VersionInfo{Major: 2, Minor: 34, Patch: 5, TargetID: 0x31100004}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reply, _ := hex.DecodeString(tt.reply)
+			client := NewAppClient(exchangeFunc(func(command []byte) ([]byte, error) {
+				assert.Equal(t, []byte{0x55, 0x00, 0, 0, 0}, command)
+				return reply, nil
+			}), AppConfig{CLA: 0x55})
+
+			version, err := client.GetVersion()
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, *version)
+		})
+	}
+
+	client := NewAppClient(exchangeFunc(func([]byte) ([]byte, error) { return []byte{0, 1}, nil }), AppConfig{CLA: 0x55})
+	_, err := client.GetVersion()
+	assert.ErrorIs(t, err, ErrInvalidResponse)
+}
+
+func TestAppClientGetAddress(t *testing.T) {
+	pubKey := bytes.Repeat([]byte{0x02}, 33)
+	address := "cosmos1abc"
+
+	var sent []byte
+	device := exchangeFunc(func(command []byte) ([]byte, error) {
+		sent = command
+		return append(append([]byte{}, pubKey...), address...), nil
+	})
+
+	client := NewAppClient(device, AppConfig{CLA: 0x55, InsGetAddr: 0x04, PubKeyLength: 33})
+	info, err := client.GetAddressWithData(testPath, true, []byte("cosmos"))
+	require.NoError(t, err)
+	assert.Equal(t, pubKey, info.PubKey)
+	assert.Equal(t, address, info.Address)
+	assert.Equal(t, "550401001a2c00008076000080000000800000000000000000636f736d6f73", hex.EncodeToString(sent))
+
+	// Length prefixed public key
+	client = NewAppClient(exchangeFunc(func(command []byte) ([]byte, error) {
+		sent = command
+		return append([]byte{0x03, 0xaa, 0xbb, 0xcc}, "f1xyz"...), nil
+	}), AppConfig{CLA: 0x06})
+
+	info, err = client.GetAddress(testPath, false)
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0xaa, 0xbb, 0xcc}, info.PubKey)
+	assert.Equal(t, "f1xyz", info.Address)
+	assert.Equal(t, byte(P1GetAddrSilent), sent[2])
+
+	client = NewAppClient(exchangeFunc(func([]byte) ([]byte, error) { return []byte{0x05, 0xaa}, nil }), AppConfig{CLA: 0x06})
+	_, err = client.GetAddress(testPath, false)
+	assert.ErrorIs(t, err, ErrInvalidResponse)
+}
+
+func TestAppClientSign(t *testing.T) {
+	message := make([]byte, 600)
+	for i := range message {
+		message[i] = byte(i)
+	}
+	signature := []byte{0x30, 0x44, 0x01}
+
+	var commands [][]byte
+	device := exchangeFunc(func(command []byte) ([]byte, error) {
+		commands = append(commands, command)
+		if command[2] == PayloadLast {
+			return signature, nil
+		}
+		return []byte{}, nil
+	})
+
+	client := NewAppClient(device, AppConfig{CLA: 0x55})
+	result, err := client.SignP2(testPath, message, 0x01)
+	require.NoError(t, err)
+	assert.Equal(t, signature, result)
+
+	require.Len(t, commands, 4)
+	assert.Equal(t, []byte{0x55, InsSign, PayloadInit, 0x01, 20}, commands[0][:5])
+	assert.Equal(t, []byte{0x55, InsSign, PayloadAdd, 0x01, 250}, commands[1][:5])
+	assert.Equal(t, []byte{0x55, InsSign, PayloadAdd, 0x01, 250}, commands[2][:5])
+	assert.Equal(t, []byte{0x55, InsSign, PayloadLast, 0x01, 100}, commands[3][:5])
+
+	var reassembled []byte
+	for _, command := range commands[1:] {
+		reassembled = append(reassembled, command[5:]...)
+	}
+	assert.Equal(t, message, reassembled)
+}
+
+func TestAppClientSignSingleChunk(t *testing.T) {
+	var commands [][]byte
+	device := exchangeFunc(func(command []byte) ([]byte, error) {
+		commands = append(commands, command)
+		return []byte{0x01}, nil
+	})
+
+	client := NewAppClient(device, AppConfig{CLA: 0x55})
+	_, err := client.Sign(testPath, []byte{1, 2, 3})
+	require.NoError(t, err)
+
+	require.Len(t, commands, 2)
+	assert.Equal(t, []byte{0x55, InsSign, PayloadInit, 0x00, 20}, commands[0][:5])
+	assert.Equal(t, []byte{0x55, InsSign, PayloadLast, 0x00, 3, 1, 2, 3}, commands[1])
+}
+
+func TestAppClientSignChunkSizeLimit(t *testing.T) {
+	var commands [][]byte
+	device := exchangeFunc(func(command []byte) ([]byte, error) {
+		require.NoError(t, ValidateCommand(command))
+		commands = append(commands, command)
+		return []byte{}, nil
+	})
+
+	client := NewAppClient(device, AppConfig{CLA: 0x55, ChunkSize: 300})
+	_, err := client.Sign(testPath, make([]byte, 300))
+	require.NoError(t, err)
+
+	require.Len(t, commands, 3)
+	assert.Equal(t, byte(MaxChunkSize), commands[1][4])
+	assert.Len(t, commands[1], 5+MaxChunkSize)
+	assert.Equal(t, byte(300-MaxChunkSize), commands[2][4])
+
+	client = NewAppClient(device, AppConfig{CLA: 0x55, SerializePath: func([]uint32) ([]byte, error) {
+		return make([]byte, 256), nil
+	}})
+	_, err = client.Sign(testPath, []byte{1})
+	assert.Error(t, err)
+}
+
+func TestAppClientSignEmptyMessage(t *testing.T) {
+	sent := false
+	device := exchangeFunc(func([]byte) ([]byte, error) {
+		sent = true
+		return []byte{}, nil
+	})
+
+	_, err := NewAppClient(device, AppConfig{CLA: 0x55}).Sign(testPath, nil)
+	assert.ErrorIs(t, err, ErrEmptyMessage)
+	assert.False(t, sent)
+}
+
+func TestAppClientSignError(t *testing.T) {
+	device := exchangeFunc(func(command []byte) ([]byte, error) {
+		if command[2] == PayloadLast {
+			return []byte("Unexpected field"), &APDUError{Code: 0x6984}
+		}
+		return []byte{}, nil
+	})
+
+	client := NewAppClient(device, AppConfig{CLA: 0x55})
+	_, err := client.Sign(testPath, []byte{1, 2, 3})
+	assert.EqualError(t, err, ErrorMessage(0x6984)+": Unexpected field")
+
+	sw, ok := StatusWord(err)
+	assert.True(t, ok)
+	assert.Equal(t, uint16(0x6984), sw)
+
+	_, err = client.Sign(nil, []byte{1})
+	assert.Error(t, err)
+}
+
+func TestPrepareChunks(t *testing.T) {
+	chunks := PrepareChunks([]byte{1}, make([]byte, 500), 250)
+	assert.Len(t, chunks, 3)
+
+	chunks = PrepareChunks([]byte{1}, nil, 250)
+	assert.Equal(t, [][]byte{{1}}, chunks)
+}
@@ -0,0 +1,132 @@
+/*******************************************************************************
+*   (c) Zondax AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+********************************************************************************/
+
+package ledger_go
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	CLAOpenApp = 0xE0
+
+	InsOpenApp = 0xD8
+	InsQuitApp = 0xA7
+)
+
+// Status words returned by the OS when switching apps
+const (
+	SwAppNotInstalled       = 0x6807
+	SwAppNotInstalledLegacy = 0x6984
+	SwUserRefused           = 0x5501
+	SwDeviceLocked          = 0x5515
+	SwSecurityNotSatisfied  = 0x6982
+)
+
+const (
+	ErrMsgAppNotInstalled  = "app not installed"
+	ErrMsgUserRefused      = "user refused on device"
+	ErrMsgDeviceLocked     = "device locked"
+	ErrMsgAppSwitchTimeout = "timeout waiting for app"
+)
+
+var (
+	ErrAppNotInstalled  = errors.New(ErrMsgAppNotInstalled)
+	ErrUserRefused      = errors.New(ErrMsgUserRefused)
+	ErrDeviceLocked     = errors.New(ErrMsgDeviceLocked)
+	ErrAppSwitchTimeout = errors.New(ErrMsgAppSwitchTimeout)
+)
+
+// appSwitchPollInterval is the delay between reconnection attempts in WaitForApp
+const appSwitchPollInterval = 250 * time.Millisecond
+
+// OpenApp asks the dashboard to start the app with the given name.
+// The command is only accepted by the dashboard, so any running app must be
+// quit first. On HID the device re-enumerates once the app starts: close this
+// device and use WaitForApp to get a new connection.
+func OpenApp(device LedgerDevice, name string) error {
+	if len(name) == 0 || len(name) > 0xFF {
+		return fmt.Errorf("invalid app name length %d", len(name))
+	}
+
+	command := append([]byte{CLAOpenApp, InsOpenApp, 0, 0, byte(len(name))}, name...)
+	_, err := device.Exchange(command)
+	if err != nil {
+		return fmt.Errorf("could not open app %q: %w", name, appSwitchError(err))
+	}
+	return nil
+}
+
+// QuitApp asks the running app to exit back to the dashboard.
+// As with OpenApp, HID devices re-enumerate after the switch.
+func QuitApp(device LedgerDevice) error {
+	_, err := device.Exchange([]byte{CLABolos, InsQuitApp, 0, 0, 0})
+	if err != nil {
+		return fmt.Errorf("could not quit app: %w", appSwitchError(err))
+	}
+	return nil
+}
+
+// appSwitchError maps the status words used by the OS when switching apps to typed errors.
+func appSwitchError(err error) error {
+	sw, ok := StatusWord(err)
+	if !ok {
+		return err
+	}
+
+	switch sw {
+	case SwAppNotInstalled, SwAppNotInstalledLegacy:
+		return fmt.Errorf("%w: %w", ErrAppNotInstalled, err)
+	case SwUserRefused:
+		return fmt.Errorf("%w: %w", ErrUserRefused, err)
+	case SwDeviceLocked, SwSecurityNotSatisfied:
+		return fmt.Errorf("%w: %w", ErrDeviceLocked, err)
+	default:
+		return err
+	}
+}
+
+// WaitForApp reconnects to the device at deviceIndex until the app with the given
+// name is running, which is needed after OpenApp or QuitApp because the device
+// re-enumerates. Use DashboardAppName to wait for the dashboard, whichever name
+// the firmware reports for it.
+func WaitForApp(admin LedgerAdmin, deviceIndex int, name string, timeout time.Duration) (LedgerDevice, error) {
+	deadline := time.Now().Add(timeout)
+
+	var lastErr error
+	for {
+		device, err := admin.Connect(deviceIndex)
+		if err == nil {
+			var info *AppInfo
+			info, err = GetAppAndVersion(device)
+			if err == nil && (info.Name == name || (isDashboardName(name) && info.IsDashboard())) {
+				return device, nil
+			}
+			if err == nil {
+				err = fmt.Errorf("running app is %q", info.Name)
+			}
+			_ = device.Close()
+		}
+		lastErr = err
+
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("%w %q after %s: %w", ErrAppSwitchTimeout, name, timeout, lastErr)
+		}
+		time.Sleep(appSwitchPollInterval)
+	}
+}
@@ -0,0 +1,148 @@
+/*******************************************************************************
+*   (c) Zondax AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+********************************************************************************/
+
+package ledger_go
+
+import (
+	"encoding/hex"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// exchangeFunc adapts a function to the LedgerDevice interface
+type exchangeFunc func(command []byte) ([]byte, error)
+
+func (f exchangeFunc) Exchange(command []byte) ([]byte, error) {
+	return f(command)
+}
+
+func (f exchangeFunc) Close() error {
+	return nil
+}
+
+// replyWith returns a device that expects a single command and answers with the given status word
+func replyWith(t *testing.T, expected string, sw uint16) exchangeFunc {
+	return func(command []byte) ([]byte, error) {
+		assert.Equal(t, expected, hex.EncodeToString(command))
+		if sw != 0x9000 {
+			return nil, &APDUError{Code: sw}
+		}
+		return []byte{}, nil
+	}
+}
+
+func TestOpenApp(t *testing.T) {
+	err := OpenApp(replyWith(t, "e0d8000007426974636f696e", 0x9000), "Bitcoin")
+	assert.NoError(t, err)
+}
+
+func TestOpenAppErrors(t *testing.T) {
+	tests := []struct {
+		sw       uint16
+		expected error
+	}{
+		{SwAppNotInstalled, ErrAppNotInstalled},
+		{SwAppNotInstalledLegacy, ErrAppNotInstalled},
+		{SwUserRefused, ErrUserRefused},
+		{SwDeviceLocked, ErrDeviceLocked},
+		{SwSecurityNotSatisfied, ErrDeviceLocked},
+	}
+
+	for _, tt := range tests {
+		err := OpenApp(replyWith(t, "e0d8000007426974636f696e", tt.sw), "Bitcoin")
+		assert.ErrorIs(t, err, tt.expected)
+
+		sw, ok := StatusWord(err)
+		assert.True(t, ok)
+		assert.Equal(t, tt.sw, sw)
+	}
+
+	err := OpenApp(replyWith(t, "e0d8000007426974636f696e", 0x6E00), "Bitcoin")
+	assert.EqualError(t, err, `could not open app "Bitcoin": `+ErrorMessage(0x6E00))
+
+	assert.Error(t, OpenApp(replyWith(t, "", 0x9000), ""))
+}
+
+func TestQuitApp(t *testing.T) {
+	assert.NoError(t, QuitApp(replyWith(t, "b0a7000000", 0x9000)))
+	assert.ErrorIs(t, QuitApp(replyWith(t, "b0a7000000", SwDeviceLocked)), ErrDeviceLocked)
+}
+
+// switchingAdmin simulates a device that disappears for a few connection attempts after an app switch
+type switchingAdmin struct {
+	unavailable int
+	apps        []string
+}
+
+func (admin *switchingAdmin) CountDevices() int {
+	return 1
+}
+
+func (admin *switchingAdmin) ListDevices() ([]string, error) {
+	return []string{"switching"}, nil
+}
+
+func (admin *switchingAdmin) Connect(int) (LedgerDevice, error) {
+	if admin.unavailable > 0 {
+		admin.unavailable--
+		return nil, errors.New("device not found")
+	}
+
+	name := admin.apps[0]
+	if len(admin.apps) > 1 {
+		admin.apps = admin.apps[1:]
+	}
+
+	return exchangeFunc(func([]byte) ([]byte, error) {
+		reply := append([]byte{0x01, byte(len(name))}, name...)
+		return append(reply, 0x01, '1', 0x01, 0x00), nil
+	}), nil
+}
+
+func TestWaitForApp(t *testing.T) {
+	admin := &switchingAdmin{unavailable: 2, apps: []string{DashboardAppName, "Bitcoin"}}
+
+	device, err := WaitForApp(admin, 0, "Bitcoin", 5*time.Second)
+	require.NoError(t, err)
+	require.NotNil(t, device)
+
+	info, err := GetAppAndVersion(device)
+	require.NoError(t, err)
+	assert.Equal(t, "Bitcoin", info.Name)
+}
+
+func TestWaitForLegacyDashboard(t *testing.T) {
+	admin := &switchingAdmin{apps: []string{"Bitcoin", DashboardAppNameLegacy}}
+
+	device, err := WaitForApp(admin, 0, DashboardAppName, 5*time.Second)
+	require.NoError(t, err)
+
+	info, err := GetAppAndVersion(device)
+	require.NoError(t, err)
+	assert.True(t, info.IsDashboard())
+}
+
+func TestWaitForAppTimeout(t *testing.T) {
+	admin := &switchingAdmin{apps: []string{DashboardAppName}}
+
+	_, err := WaitForApp(admin, 0, "Bitcoin", 0)
+	assert.ErrorIs(t, err, ErrAppSwitchTimeout)
+	assert.Contains(t, err.Error(), `running app is "BOLOS"`)
+}
@@ -0,0 +1,290 @@
+/*******************************************************************************
+*   (c) Zondax AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+********************************************************************************/
+
+// Package bip32 parses, validates and encodes BIP32/BIP44 derivation paths
+// in the layouts expected by Ledger apps.
+package bip32
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/zondax/ledger-go/slip44"
+)
+
+const (
+	// Hardened is the bit set on hardened path components
+	Hardened uint32 = 0x80000000
+
+	// MaxDepth is the maximum number of components accepted by Ledger apps
+	MaxDepth = 10
+)
+
+// Well known purposes
+const (
+	PurposeBIP44 uint32 = 44
+	PurposeBIP49 uint32 = 49
+	PurposeBIP84 uint32 = 84
+	PurposeBIP86 uint32 = 86
+)
+
+const (
+	ErrMsgInvalidPath      = "invalid derivation path"
+	ErrMsgCoinTypeMismatch = "coin type mismatch"
+)
+
+var (
+	ErrInvalidPath      = errors.New(ErrMsgInvalidPath)
+	ErrCoinTypeMismatch = errors.New(ErrMsgCoinTypeMismatch)
+)
+
+// Path is a derivation path, hardened components have the Hardened bit set.
+type Path []uint32
+
+// Parse parses paths like "m/44'/118'/0'/0/0". The "m/" prefix is optional and
+// hardened components may be marked with ', h or H.
+func Parse(s string) (Path, error) {
+	text := strings.TrimSpace(s)
+	text = strings.TrimPrefix(text, "m/")
+	if text == "m" || text == "" {
+		return Path{}, nil
+	}
+
+	parts := strings.Split(text, "/")
+	if len(parts) > MaxDepth {
+		return nil, fmt.Errorf("%w: %q has more than %d components", ErrInvalidPath, s, MaxDepth)
+	}
+
+	path := make(Path, 0, len(parts))
+	for _, part := range parts {
+		var hardened uint32
+		if strings.HasSuffix(part, "'") || strings.HasSuffix(part, "h") || strings.HasSuffix(part, "H") {
+			hardened = Hardened
+			part = part[:len(part)-1]
+		}
+
+		value, err := strconv.ParseUint(part, 10, 32)
+		if err != nil || uint32(value)&Hardened != 0 {
+			return nil, fmt.Errorf("%w: %q: bad component %q", ErrInvalidPath, s, part)
+		}
+
+		path = append(path, uint32(value)|hardened)
+	}
+
+	return path, nil
+}
+
+// MustParse is like Parse but panics on error. It is meant for constants.
+func MustParse(s string) Path {
+	path, err := Parse(s)
+	if err != nil {
+		panic(err)
+	}
+	return path
+}
+
+// String returns the canonical representation, using ' for hardened components.
+func (p Path) String() string {
+	var sb strings.Builder
+	sb.WriteString("m")
+	for _, component := range p {
+		sb.WriteString("/")
+		sb.WriteString(strconv.FormatUint(uint64(component&^Hardened), 10))
+		if component&Hardened != 0 {
+			sb.WriteString("'")
+		}
+	}
+	return sb.String()
+}
+
+// IsHardened reports whether the component at level is hardened.
+func (p Path) IsHardened(level int) bool {
+	return level < len(p) && p[level]&Hardened != 0
+}
+
+// Purpose returns the purpose (first component) without the hardened bit.
+func (p Path) Purpose() (uint32, bool) {
+	if len(p) < 1 {
+		return 0, false
+	}
+	return p[0] &^ Hardened, true
+}
+
+// CoinType returns the coin type (second component) without the hardened bit.
+func (p Path) CoinType() (uint32, bool) {
+	if len(p) < 2 {
+		return 0, false
+	}
+	return p[1] &^ Hardened, true
+}
+
+// Rules describe the shape a path must have.
+type Rules struct {
+	MinDepth int
+	MaxDepth int
+
+	// HardenedLevels is the number of leading components that must be hardened
+	HardenedLevels int
+
+	// AllHardened requires every component to be hardened, as in SLIP-10 ed25519 derivation
+	AllHardened bool
+}
+
+var (
+	// BIP44Rules matches purpose'/coin'/account'/change/index paths
+	BIP44Rules = Rules{MinDepth: 5, MaxDepth: 5, HardenedLevels: 3}
+
+	// SLIP10Rules matches ed25519 paths where every component is hardened
+	SLIP10Rules = Rules{MinDepth: 1, MaxDepth: MaxDepth, AllHardened: true}
+)
+
+// purposeRules are applied by Validate according to the purpose of the path.
+// Lower levels may be hardened, since ed25519 coins harden every component.
+var purposeRules = map[uint32]Rules{
+	PurposeBIP44: {MinDepth: 3, MaxDepth: 5, HardenedLevels: 3},
+	PurposeBIP49: {MinDepth: 3, MaxDepth: 5, HardenedLevels: 3},
+	PurposeBIP84: {MinDepth: 3, MaxDepth: 5, HardenedLevels: 3},
+	PurposeBIP86: {MinDepth: 3, MaxDepth: 5, HardenedLevels: 3},
+}
+
+// Validate checks the depth of the path and, for well known purposes, that the
+// purpose, coin type and account components are hardened.
+func (p Path) Validate() error {
+	rules := Rules{MinDepth: 1, MaxDepth: MaxDepth}
+	if purpose, ok := p.Purpose(); ok && p.IsHardened(0) {
+		if r, found := purposeRules[purpose]; found {
+			rules = r
+		}
+	}
+	return p.ValidateWith(rules)
+}
+
+// ValidateWith checks the path against rules.
+func (p Path) ValidateWith(rules Rules) error {
+	if len(p) < rules.MinDepth {
+		return fmt.Errorf("%w: %s has depth %d, at least %d required", ErrInvalidPath, p, len(p), rules.MinDepth)
+	}
+
+	maxDepth := rules.MaxDepth
+	if maxDepth == 0 || maxDepth > MaxDepth {
+		maxDepth = MaxDepth
+	}
+	if len(p) > maxDepth {
+		return fmt.Errorf("%w: %s has depth %d, at most %d allowed", ErrInvalidPath, p, len(p), maxDepth)
+	}
+
+	for level := range p {
+		if (rules.AllHardened || level < rules.HardenedLevels) && !p.IsHardened(level) {
+			return fmt.Errorf("%w: %s: component %d must be hardened", ErrInvalidPath, p, level)
+		}
+	}
+
+	return nil
+}
+
+// Encode serializes the path as 4 byte components in the given byte order,
+// optionally preceded by a one byte component count.
+func (p Path) Encode(order binary.ByteOrder, lengthPrefix bool) []byte {
+	var result []byte
+	if lengthPrefix {
+		result = append(result, byte(len(p)))
+	}
+
+	buffer := make([]byte, 4)
+	for _, component := range p {
+		order.PutUint32(buffer, component)
+		result = append(result, buffer...)
+	}
+	return result
+}
+
+// Bytes returns the layout used by most Ledger apps: count byte followed by big endian components.
+func (p Path) Bytes() []byte {
+	return p.Encode(binary.BigEndian, true)
+}
+
+// BytesLE returns the layout used by most Zondax apps: little endian components without a count.
+func (p Path) BytesLE() []byte {
+	return p.Encode(binary.LittleEndian, false)
+}
+
+// Decode is the inverse of Encode.
+func Decode(data []byte, order binary.ByteOrder, lengthPrefix bool) (Path, error) {
+	count := len(data) / 4
+	if lengthPrefix {
+		if len(data) < 1 {
+			return nil, fmt.Errorf("%w: missing component count", ErrInvalidPath)
+		}
+		count = int(data[0])
+		data = data[1:]
+	}
+
+	if len(data) != 4*count {
+		return nil, fmt.Errorf("%w: %d bytes cannot hold %d components", ErrInvalidPath, len(data), count)
+	}
+	if count > MaxDepth {
+		return nil, fmt.Errorf("%w: more than %d components", ErrInvalidPath, MaxDepth)
+	}
+
+	path := make(Path, count)
+	for i := range path {
+		path[i] = order.Uint32(data[4*i:])
+	}
+	return path, nil
+}
+
+// Coin returns the SLIP-44 registry entry for the coin type of the path.
+func (p Path) Coin() (slip44.Coin, bool) {
+	coinType, ok := p.CoinType()
+	if !ok {
+		return slip44.Coin{}, false
+	}
+	return slip44.Lookup(coinType)
+}
+
+// Label returns the canonical path followed by the coin name when known,
+// e.g. "m/44'/461'/0'/0/0 (Filecoin)".
+func (p Path) Label() string {
+	if coin, ok := p.Coin(); ok {
+		return fmt.Sprintf("%s (%s)", p, coin.Name)
+	}
+	return p.String()
+}
+
+// RequireCoinType checks that the path targets the expected coin type, so that
+// mismatches are caught before a request reaches the device.
+func (p Path) RequireCoinType(coinType uint32) error {
+	actual, ok := p.CoinType()
+	if ok && actual == coinType&^Hardened {
+		return nil
+	}
+
+	expected := coinName(coinType)
+	if !ok {
+		return fmt.Errorf("%w: %s has no coin type, expected %s", ErrCoinTypeMismatch, p, expected)
+	}
+	return fmt.Errorf("%w: %s is for %s, expected %s", ErrCoinTypeMismatch, p, coinName(actual), expected)
+}
+
+func coinName(coinType uint32) string {
+	if coin, ok := slip44.Lookup(coinType); ok {
+		return fmt.Sprintf("%s (%d)", coin.Name, coinType&^Hardened)
+	}
+	return fmt.Sprintf("coin type %d", coinType&^Hardened)
+}
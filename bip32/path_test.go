@@ -0,0 +1,141 @@
+/*******************************************************************************
+*   (c) Zondax AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+********************************************************************************/
+
+package bip32
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		input     string
+		expected  Path
+		canonical string
+	}{
+		{"m/44'/118'/0'/0/0", Path{44 | Hardened, 118 | Hardened, Hardened, 0, 0}, "m/44'/118'/0'/0/0"},
+		{"44h/461H/0h/0/7", Path{44 | Hardened, 461 | Hardened, Hardened, 0, 7}, "m/44'/461'/0'/0/7"},
+		{"m/2147483647'", Path{0xFFFFFFFF}, "m/2147483647'"},
+		{"m", Path{}, "m"},
+	}
+
+	for _, tt := range tests {
+		path, err := Parse(tt.input)
+		require.NoError(t, err, tt.input)
+		assert.Equal(t, tt.expected, path, tt.input)
+		assert.Equal(t, tt.canonical, path.String(), tt.input)
+	}
+
+	for _, input := range []string{"m/44'/", "m/x/0", "m/2147483648", "m/-1", "m/0/0/0/0/0/0/0/0/0/0/0", "n/44'"} {
+		_, err := Parse(input)
+		assert.ErrorIs(t, err, ErrInvalidPath, input)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	valid := []string{"m/44'/118'/0'/0/0", "m/44'/501'/0'/0'", "m/84'/0'/0'", "m/0/1", "m/1852'/1815'/0'/0/0"}
+	for _, input := range valid {
+		assert.NoError(t, MustParse(input).Validate(), input)
+	}
+
+	invalid := []string{"m/44'/118'/0/0/0", "m/44'/118'", "m/44'/118'/0'/0/0/0", "m/84'/0/0'", "m"}
+	for _, input := range invalid {
+		assert.ErrorIs(t, MustParse(input).Validate(), ErrInvalidPath, input)
+	}
+
+	assert.NoError(t, MustParse("m/44'/118'/0'/0/0").ValidateWith(BIP44Rules))
+	assert.Error(t, MustParse("m/44'/118'/0'").ValidateWith(BIP44Rules))
+	assert.NoError(t, MustParse("m/44'/354'/0'/0'/0'").ValidateWith(SLIP10Rules))
+	assert.Error(t, MustParse("m/44'/354'/0'/0'/0").ValidateWith(SLIP10Rules))
+}
+
+func TestAccessors(t *testing.T) {
+	path := MustParse("m/44'/461'/0'/0/1")
+
+	purpose, ok := path.Purpose()
+	assert.True(t, ok)
+	assert.Equal(t, PurposeBIP44, purpose)
+
+	coin, ok := path.CoinType()
+	assert.True(t, ok)
+	assert.Equal(t, uint32(461), coin)
+
+	assert.True(t, path.IsHardened(2))
+	assert.False(t, path.IsHardened(3))
+	assert.False(t, path.IsHardened(7))
+
+	_, ok = Path{}.CoinType()
+	assert.False(t, ok)
+}
+
+func TestEncode(t *testing.T) {
+	path := MustParse("m/44'/118'/0'/0/0")
+
+	assert.Equal(t, "058000002c80000076800000000000000000000000", hex.EncodeToString(path.Bytes()))
+	assert.Equal(t, "2c00008076000080000000800000000000000000", hex.EncodeToString(path.BytesLE()))
+	assert.Equal(t, "8000002c80000076800000000000000000000000", hex.EncodeToString(path.Encode(binary.BigEndian, false)))
+	assert.Equal(t, "052c00008076000080000000800000000000000000", hex.EncodeToString(path.Encode(binary.LittleEndian, true)))
+}
+
+func TestDecode(t *testing.T) {
+	path := MustParse("m/44'/118'/0'/0/3")
+
+	for _, order := range []binary.ByteOrder{binary.BigEndian, binary.LittleEndian} {
+		for _, prefix := range []bool{true, false} {
+			decoded, err := Decode(path.Encode(order, prefix), order, prefix)
+			require.NoError(t, err)
+			assert.Equal(t, path, decoded)
+		}
+	}
+
+	_, err := Decode([]byte{0x02, 0, 0, 0, 1}, binary.BigEndian, true)
+	assert.ErrorIs(t, err, ErrInvalidPath)
+
+	_, err = Decode([]byte{0, 0, 1}, binary.BigEndian, false)
+	assert.ErrorIs(t, err, ErrInvalidPath)
+
+	_, err = Decode(nil, binary.BigEndian, true)
+	assert.ErrorIs(t, err, ErrInvalidPath)
+}
+
+func TestCoin(t *testing.T) {
+	path := MustParse("m/44'/461'/0'/0/0")
+	coin, ok := path.Coin()
+	require.True(t, ok)
+	assert.Equal(t, "FIL", coin.Symbol)
+	assert.Equal(t, "m/44'/461'/0'/0/0 (Filecoin)", path.Label())
+
+	assert.Equal(t, "m/44'/4242424'/0'", MustParse("m/44'/4242424'/0'").Label())
+	assert.Equal(t, "m/44'", MustParse("m/44'").Label())
+}
+
+func TestRequireCoinType(t *testing.T) {
+	path := MustParse("m/44'/461'/0'/0/0")
+	assert.NoError(t, path.RequireCoinType(461))
+	assert.NoError(t, path.RequireCoinType(461|Hardened))
+
+	err := path.RequireCoinType(118)
+	assert.ErrorIs(t, err, ErrCoinTypeMismatch)
+	assert.EqualError(t, err, "coin type mismatch: m/44'/461'/0'/0/0 is for Filecoin (461), expected Atom (118)")
+
+	err = MustParse("m/44'").RequireCoinType(118)
+	assert.ErrorIs(t, err, ErrCoinTypeMismatch)
+}
@@ -0,0 +1,106 @@
+/*******************************************************************************
+*   (c) Zondax AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+********************************************************************************/
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+
+	ledger_go "github.com/zondax/ledger-go"
+	"github.com/zondax/ledger-go/ledgertest"
+)
+
+const defaultBackend = "hid"
+
+// backend is a way to reach devices
+type backend struct {
+	admin ledger_go.LedgerAdmin
+
+	// list prints the devices, for backends describing them better than ListDevices
+	list func(w io.Writer) error
+}
+
+type backendFactory func(cfg *config) backend
+
+var backends = map[string]backendFactory{
+	"hid": func(_ *config) backend {
+		admin := ledger_go.NewLedgerAdminHID()
+		return backend{admin: admin, list: func(w io.Writer) error {
+			return printDescriptors(w, admin.Descriptors())
+		}}
+	},
+	"zemu": func(cfg *config) backend {
+		var options []ledger_go.ZemuOption
+		if cfg.zemuAddress != "" {
+			options = append(options, ledger_go.WithZemuAddress(cfg.zemuAddress))
+		}
+		return backend{admin: ledger_go.NewLedgerAdminZemu(options...)}
+	},
+	"speculos": func(cfg *config) backend {
+		var options []ledger_go.SpeculosOption
+		if cfg.speculosURL != "" {
+			options = append(options, ledger_go.WithSpeculosURL(cfg.speculosURL))
+		}
+		return backend{admin: ledger_go.NewLedgerAdminSpeculos(options...)}
+	},
+	"mock": func(_ *config) backend {
+		return backend{admin: ledgertest.NewAdmin(newMockDashboard())}
+	},
+}
+
+func printDescriptors(w io.Writer, descriptors []ledger_go.DeviceDescriptor) error {
+	table := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "INDEX\tMODEL\tVID:PID\tINTERFACE\tUSAGE PAGE\tSERIAL\tPATH")
+	for _, d := range descriptors {
+		fmt.Fprintf(table, "%d\t%s\t%04x:%04x\t%d\t%04x\t%s\t%s\n",
+			d.Index, d.Model, d.VendorID, d.ProductID, d.Interface, d.UsagePage, d.Serial, d.Path)
+	}
+	return table.Flush()
+}
+
+func backendNames() []string {
+	names := make([]string, 0, len(backends))
+	for name := range backends {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func openBackend(cfg *config) (backend, error) {
+	factory, ok := backends[cfg.backend]
+	if !ok {
+		return backend{}, fmt.Errorf("unknown backend %q, available: %v", cfg.backend, backendNames())
+	}
+	return factory(cfg), nil
+}
+
+// connect opens the selected device of the selected backend
+func connect(cfg *config) (ledger_go.LedgerAdmin, ledger_go.LedgerDevice, error) {
+	b, err := openBackend(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	device, err := b.admin.Connect(cfg.device)
+	if err != nil {
+		return nil, nil, err
+	}
+	return b.admin, device, nil
+}
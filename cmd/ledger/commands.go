@@ -0,0 +1,276 @@
+/*******************************************************************************
+*   (c) Zondax AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+********************************************************************************/
+
+package main
+
+import (
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"runtime/debug"
+	"strings"
+	"text/tabwriter"
+
+	ledger_go "github.com/zondax/ledger-go"
+)
+
+func noArguments(cfg *config, name string, args []string) error {
+	if len(args) > 0 {
+		fmt.Fprintf(cfg.stderr, "usage: ledger %s\n", name)
+		return errUsage
+	}
+	return nil
+}
+
+func runList(cfg *config, args []string) error {
+	if err := noArguments(cfg, "list", args); err != nil {
+		return err
+	}
+
+	b, err := openBackend(cfg)
+	if err != nil {
+		return err
+	}
+	if b.list != nil {
+		return b.list(cfg.stdout)
+	}
+
+	names, err := b.admin.ListDevices()
+	if err != nil {
+		return err
+	}
+	if len(names) == 0 {
+		fmt.Fprintln(cfg.stderr, "no devices found")
+		return nil
+	}
+
+	table := tabwriter.NewWriter(cfg.stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "INDEX\tNAME")
+	for i, name := range names {
+		fmt.Fprintf(table, "%d\t%s\n", i, name)
+	}
+	return table.Flush()
+}
+
+// runInfo shows the device info and running app, either of which may be unavailable
+// depending on the app
+func runInfo(cfg *config, args []string) error {
+	if err := noArguments(cfg, "info", args); err != nil {
+		return err
+	}
+
+	_, device, err := connect(cfg)
+	if err != nil {
+		return err
+	}
+	defer device.Close()
+
+	table := tabwriter.NewWriter(cfg.stdout, 0, 0, 2, ' ', 0)
+
+	deviceInfo, deviceErr := ledger_go.GetDeviceInfo(device)
+	if deviceErr == nil {
+		fmt.Fprintf(table, "Model:\t%s\n", deviceInfo.Model())
+		fmt.Fprintf(table, "Target ID:\t0x%08x\n", deviceInfo.TargetID)
+		fmt.Fprintf(table, "SE version:\t%s\n", deviceInfo.SEVersion)
+		fmt.Fprintf(table, "MCU version:\t%s\n", deviceInfo.MCUVersion)
+	} else {
+		fmt.Fprintf(table, "Device info:\tunavailable (%v)\n", deviceErr)
+	}
+
+	appInfo, appErr := ledger_go.GetAppAndVersion(device)
+	if appErr == nil {
+		fmt.Fprintf(table, "App:\t%s\n", appInfo.Name)
+		fmt.Fprintf(table, "App version:\t%s\n", appInfo.Version)
+	} else {
+		fmt.Fprintf(table, "App:\tunavailable (%v)\n", appErr)
+	}
+
+	if err := table.Flush(); err != nil {
+		return err
+	}
+	if deviceErr != nil && appErr != nil {
+		return errors.Join(deviceErr, appErr)
+	}
+	return nil
+}
+
+// parseHex decodes hex split in any number of arguments, ignoring spaces and colons
+func parseHex(args []string) ([]byte, error) {
+	cleaned := strings.NewReplacer(" ", "", ":", "", "\t", "").Replace(strings.Join(args, ""))
+	cleaned = strings.TrimPrefix(strings.ToLower(cleaned), "0x")
+	return hex.DecodeString(cleaned)
+}
+
+// runAPDU prints the reply data and status word in hex, as "<data> <sw>".
+// Error status words are a valid reply, they only fail the command when the exchange itself fails.
+func runAPDU(cfg *config, args []string) error {
+	if len(args) == 0 {
+		fmt.Fprintln(cfg.stderr, "usage: ledger apdu <hex>")
+		return errUsage
+	}
+
+	command, err := parseHex(args)
+	if err != nil {
+		return fmt.Errorf("invalid APDU: %w", err)
+	}
+
+	_, device, err := connect(cfg)
+	if err != nil {
+		return err
+	}
+	defer device.Close()
+
+	response, err := device.Exchange(command)
+	sw := uint16(ledger_go.SwOK)
+	if err != nil {
+		code, ok := ledger_go.StatusWord(err)
+		if !ok {
+			return err
+		}
+		sw = code
+		fmt.Fprintln(cfg.stderr, ledger_go.ErrorMessage(sw))
+	}
+
+	if len(response) > 0 {
+		fmt.Fprintf(cfg.stdout, "%x ", response)
+	}
+	fmt.Fprintf(cfg.stdout, "%04x\n", sw)
+	return nil
+}
+
+func runOpen(cfg *config, args []string) error {
+	if len(args) != 1 {
+		fmt.Fprintln(cfg.stderr, "usage: ledger open <app>")
+		return errUsage
+	}
+	name := args[0]
+
+	admin, device, err := connect(cfg)
+	if err != nil {
+		return err
+	}
+	err = ledger_go.OpenApp(device, name)
+	_ = device.Close()
+	if err != nil {
+		return err
+	}
+
+	return waitForApp(cfg, admin, name)
+}
+
+func runQuit(cfg *config, args []string) error {
+	if err := noArguments(cfg, "quit", args); err != nil {
+		return err
+	}
+
+	admin, device, err := connect(cfg)
+	if err != nil {
+		return err
+	}
+	err = ledger_go.QuitApp(device)
+	_ = device.Close()
+	if err != nil {
+		return err
+	}
+
+	return waitForApp(cfg, admin, ledger_go.DashboardAppName)
+}
+
+// waitForApp waits for the device to come back running name, unless waiting is disabled
+func waitForApp(cfg *config, admin ledger_go.LedgerAdmin, name string) error {
+	if cfg.wait <= 0 {
+		return nil
+	}
+
+	device, err := ledger_go.WaitForApp(admin, cfg.device, name, cfg.wait)
+	if err != nil {
+		return err
+	}
+	_ = device.Close()
+
+	fmt.Fprintf(cfg.stdout, "%s is running\n", name)
+	return nil
+}
+
+// runScript runs the steps of an APDU script and prints the report.
+// The command fails when a step fails, so that it can be used in CI.
+func runScript(cfg *config, args []string) error {
+	flags := flag.NewFlagSet("script", flag.ContinueOnError)
+	flags.SetOutput(cfg.stderr)
+	continueOnMismatch := flags.Bool("continue", false, "run the remaining steps after a failure")
+	flags.Usage = func() {
+		fmt.Fprintln(cfg.stderr, "usage: ledger script [-continue] <file>")
+		flags.PrintDefaults()
+	}
+
+	if err := flags.Parse(args); err != nil {
+		return errUsage
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return errUsage
+	}
+
+	steps, err := readScript(cfg, flags.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	_, device, err := connect(cfg)
+	if err != nil {
+		return err
+	}
+	defer device.Close()
+
+	report := ledger_go.RunScript(device, steps, ledger_go.ScriptOptions{ContinueOnMismatch: *continueOnMismatch})
+	if err := report.Write(cfg.stdout); err != nil {
+		return err
+	}
+
+	if !report.Passed() {
+		return fmt.Errorf("%d of %d steps failed", report.Failed(), len(steps))
+	}
+	return nil
+}
+
+func readScript(cfg *config, path string) ([]ledger_go.ScriptStep, error) {
+	var r io.Reader = cfg.stdin
+	if path != "-" {
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		r = file
+	}
+	return ledger_go.ParseScript(r)
+}
+
+func runVersion(cfg *config, args []string) error {
+	if err := noArguments(cfg, "version", args); err != nil {
+		return err
+	}
+
+	version := "unknown"
+	if info, ok := debug.ReadBuildInfo(); ok {
+		version = info.Main.Version
+	}
+	fmt.Fprintf(cfg.stdout, "ledger %s\n", version)
+	return nil
+}
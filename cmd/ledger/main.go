@@ -0,0 +1,133 @@
+/*******************************************************************************
+*   (c) Zondax AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+********************************************************************************/
+
+// Command ledger talks to Ledger devices and emulators for diagnostics.
+//
+//	ledger [flags] <command> [arguments]
+//
+// Commands:
+//
+//	list             list the devices of the backend
+//	info             show the device and running app
+//	apdu <hex>       send a raw APDU and print the reply data and status word
+//	open <app>       open an app from the dashboard
+//	quit             quit the running app
+//	script <file>    run an APDU script and report the steps that failed
+//	version          print the tool version
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// errUsage is returned for invalid command lines, after the usage was printed
+var errUsage = errors.New("invalid usage")
+
+// config holds the global flags
+type config struct {
+	backend     string
+	device      int
+	zemuAddress string
+	speculosURL string
+	wait        time.Duration
+
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+}
+
+type command struct {
+	usage string
+	run   func(cfg *config, args []string) error
+}
+
+var commands = map[string]command{
+	"list":    {"list the devices of the backend", runList},
+	"info":    {"show the device and running app", runInfo},
+	"apdu":    {"send a raw APDU given in hex and print the reply data and status word", runAPDU},
+	"open":    {"open an app from the dashboard", runOpen},
+	"quit":    {"quit the running app", runQuit},
+	"script":  {"run an APDU script, - reads it from stdin", runScript},
+	"version": {"print the tool version", runVersion},
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// run executes the command line and returns the exit code
+func run(args []string, stdout io.Writer, stderr io.Writer) int {
+	cfg := &config{stdin: os.Stdin, stdout: stdout, stderr: stderr}
+
+	flags := flag.NewFlagSet("ledger", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.StringVar(&cfg.backend, "backend", defaultBackend, "backend: "+strings.Join(backendNames(), ", "))
+	flags.IntVar(&cfg.device, "device", 0, "index of the device, as shown by list")
+	flags.StringVar(&cfg.zemuAddress, "zemu", "", "Zemu address, as host:port, for the zemu backend")
+	flags.StringVar(&cfg.speculosURL, "speculos", "", "Speculos API address, as http://host:port")
+	flags.DurationVar(&cfg.wait, "wait", 10*time.Second, "how long open and quit wait for the app switch, 0 to not wait")
+	flags.Usage = func() { usage(flags) }
+
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	if flags.NArg() == 0 {
+		usage(flags)
+		return 2
+	}
+
+	name := flags.Arg(0)
+	cmd, ok := commands[name]
+	if !ok {
+		fmt.Fprintf(stderr, "unknown command %q\n", name)
+		usage(flags)
+		return 2
+	}
+
+	if err := cmd.run(cfg, flags.Args()[1:]); err != nil {
+		if errors.Is(err, errUsage) {
+			return 2
+		}
+		fmt.Fprintf(stderr, "ledger %s: %v\n", name, err)
+		return 1
+	}
+	return 0
+}
+
+func usage(flags *flag.FlagSet) {
+	out := flags.Output()
+	fmt.Fprintf(out, "Usage: ledger [flags] <command> [arguments]\n\nCommands:\n")
+
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(out, "  %-8s %s\n", name, commands[name].usage)
+	}
+
+	fmt.Fprintf(out, "\nFlags:\n")
+	flags.PrintDefaults()
+}
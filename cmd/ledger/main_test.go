@@ -0,0 +1,149 @@
+/*******************************************************************************
+*   (c) Zondax AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+********************************************************************************/
+
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	ledger_go "github.com/zondax/ledger-go"
+)
+
+// runMock runs the command line against the mock backend and returns the exit code and outputs
+func runMock(args ...string) (int, string, string) {
+	var stdout, stderr bytes.Buffer
+	code := run(append([]string{"-backend", "mock", "-wait", "1s"}, args...), &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+func TestList(t *testing.T) {
+	code, stdout, _ := runMock("list")
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "INDEX  NAME\n0      Mock device 0\n", stdout)
+}
+
+func TestInfo(t *testing.T) {
+	code, stdout, _ := runMock("info")
+	assert.Equal(t, 0, code)
+	assert.Contains(t, stdout, "Model:        Nano S Plus\n")
+	assert.Contains(t, stdout, "MCU version:  5.24\n")
+	assert.Contains(t, stdout, "App:          BOLOS\n")
+}
+
+func TestAPDU(t *testing.T) {
+	code, stdout, _ := runMock("apdu", "b0 01 00 00 00")
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "0105424f4c4f5305312e312e310102 9000\n", stdout)
+
+	// Error status words are printed, not failures
+	code, stdout, stderr := runMock("apdu", "0xb0", "09:00:00:00")
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "6d00\n", stdout)
+	assert.Equal(t, ledger_go.ErrorMessage(ledger_go.SwInsNotSupported)+"\n", stderr)
+
+	code, _, stderr = runMock("apdu", "b00100")
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stderr, "APDU commands should not be smaller than 5")
+
+	code, _, stderr = runMock("apdu", "zz")
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stderr, "invalid APDU")
+
+	code, _, _ = runMock("apdu")
+	assert.Equal(t, 2, code)
+}
+
+func TestOpenAndQuit(t *testing.T) {
+	code, _, stderr := runMock("open", "Monero")
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stderr, "app not installed")
+
+	code, stdout, _ := runMock("open", "Bitcoin")
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "Bitcoin is running\n", stdout)
+
+	code, stdout, _ = runMock("quit")
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "BOLOS is running\n", stdout)
+}
+
+func TestMockDashboard(t *testing.T) {
+	device := newMockDashboard()
+
+	_, err := device.Exchange([]byte{0xe0, 0xd8, 0x00, 0x00, 0x07, 'B', 'i', 't', 'c', 'o', 'i', 'n'})
+	assert.NoError(t, err)
+
+	// Apps are only opened from the dashboard
+	_, err = device.Exchange([]byte{0xe0, 0xd8, 0x00, 0x00, 0x08, 'E', 't', 'h', 'e', 'r', 'e', 'u', 'm'})
+	assert.Error(t, err)
+}
+
+func TestScript(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dashboard.apdu")
+	script := "# Dashboard\nb001000000 => 0105424f4c4f53.*9000\ne0d8000006 4d6f6e65726f => 6807\ne0d8000006 4d6f6e65726f\nb001000000\n"
+	assert.NoError(t, os.WriteFile(path, []byte(script), 0o600))
+
+	code, stdout, stderr := runMock("script", path)
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stdout, "PASS line 2: b001000000 => 0105424f4c4f5305312e312e3101029000\n")
+	assert.Contains(t, stdout, "FAIL line 4: e0d80000064d6f6e65726f => 6807: unexpected reply")
+	assert.Contains(t, stdout, "2 passed, 1 failed, 1 skipped\n")
+	assert.Contains(t, stderr, "1 of 4 steps failed")
+
+	code, stdout, _ = runMock("script", "-continue", path)
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stdout, "3 passed, 1 failed, 0 skipped\n")
+
+	code, _, stderr = runMock("script", filepath.Join(t.TempDir(), "missing.apdu"))
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stderr, "no such file")
+
+	code, _, _ = runMock("script")
+	assert.Equal(t, 2, code)
+}
+
+func TestUsageErrors(t *testing.T) {
+	code, _, stderr := runMock()
+	assert.Equal(t, 2, code)
+	assert.Contains(t, stderr, "Usage: ledger")
+
+	code, _, stderr = runMock("flash")
+	assert.Equal(t, 2, code)
+	assert.Contains(t, stderr, `unknown command "flash"`)
+
+	code, _, _ = runMock("list", "extra")
+	assert.Equal(t, 2, code)
+
+	var stdout, stderr2 bytes.Buffer
+	code = run([]string{"-backend", "usb3", "info"}, &stdout, &stderr2)
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stderr2.String(), `unknown backend "usb3"`)
+}
+
+func TestBackends(t *testing.T) {
+	assert.Equal(t, []string{"hid", "mock", "speculos", "zemu"}, backendNames())
+
+	b, err := openBackend(&config{backend: "zemu", zemuAddress: "127.0.0.1:4000"})
+	assert.NoError(t, err)
+	admin, ok := b.admin.(*ledger_go.LedgerAdminZemu)
+	assert.True(t, ok)
+	assert.Equal(t, "127.0.0.1:4000", admin.Address())
+}
@@ -0,0 +1,85 @@
+/*******************************************************************************
+*   (c) Zondax AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+********************************************************************************/
+
+package main
+
+import (
+	"encoding/binary"
+
+	ledger_go "github.com/zondax/ledger-go"
+)
+
+const (
+	mockTargetID       = 0x33100004
+	mockSEVersion      = "1.1.1"
+	mockMCUVersion     = "5.24"
+	mockBolosVersion   = "1.1.1"
+	mockDashboardFlags = 0x02
+)
+
+// mockApps are the apps installed on the mock device, with their versions
+var mockApps = map[string]string{
+	"Bitcoin":  "2.1.0",
+	"Ethereum": "1.10.4",
+}
+
+// newMockDashboard returns a virtual device answering the dashboard commands, to try the tool
+// without a device. Handlers are serialized by the device, so the running app needs no lock.
+func newMockDashboard() *ledger_go.VirtualDevice {
+	device := ledger_go.NewVirtualDevice()
+	running := ledger_go.DashboardAppName
+
+	device.Handle(ledger_go.CLABolos, ledger_go.InsGetAppAndVersion, func(_ *ledger_go.Command) ([]byte, uint16) {
+		version := mockBolosVersion
+		if running != ledger_go.DashboardAppName {
+			version = mockApps[running]
+		}
+
+		response := []byte{0x01, byte(len(running))}
+		response = append(response, running...)
+		response = append(response, byte(len(version)))
+		response = append(response, version...)
+		return append(response, 0x01, mockDashboardFlags), ledger_go.SwOK
+	})
+
+	device.Handle(ledger_go.CLADeviceInfo, ledger_go.InsGetDeviceInfo, func(_ *ledger_go.Command) ([]byte, uint16) {
+		response := binary.BigEndian.AppendUint32(nil, mockTargetID)
+		response = append(response, byte(len(mockSEVersion)))
+		response = append(response, mockSEVersion...)
+		response = append(response, 0x04, 0x00, 0x00, 0x00, 0x00)
+		response = append(response, byte(len(mockMCUVersion)+1))
+		return append(append(response, mockMCUVersion...), 0x00), ledger_go.SwOK
+	})
+
+	device.Handle(ledger_go.CLAOpenApp, ledger_go.InsOpenApp, func(command *ledger_go.Command) ([]byte, uint16) {
+		name := string(command.Data)
+		switch _, installed := mockApps[name]; {
+		case running != ledger_go.DashboardAppName:
+			return nil, ledger_go.SwInsNotSupported
+		case !installed:
+			return nil, ledger_go.SwAppNotInstalled
+		}
+		running = name
+		return nil, ledger_go.SwOK
+	})
+
+	device.Handle(ledger_go.CLABolos, ledger_go.InsQuitApp, func(_ *ledger_go.Command) ([]byte, uint16) {
+		running = ledger_go.DashboardAppName
+		return nil, ledger_go.SwOK
+	})
+
+	return device
+}
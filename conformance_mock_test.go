@@ -0,0 +1,39 @@
+//go:build ledger_mock
+// +build ledger_mock
+
+/*******************************************************************************
+*   (c) Zondax AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+********************************************************************************/
+package ledger_go_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	ledger_go "github.com/zondax/ledger-go"
+	"github.com/zondax/ledger-go/ledgertest"
+)
+
+func TestConformanceMockBackend(t *testing.T) {
+	admin, ok := ledger_go.NewLedgerAdmin().(*ledger_go.LedgerAdminMock)
+	require.True(t, ok)
+
+	device, err := admin.Device(0)
+	require.NoError(t, err)
+	device.(*ledger_go.LedgerDeviceMock).SetCommandReplies(ledgertest.ReferenceReplies())
+
+	ledgertest.RunAdminConformance(t, admin, 0)
+}
@@ -0,0 +1,100 @@
+/*******************************************************************************
+*   (c) Zondax AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+********************************************************************************/
+package ledger_go_test
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	ledger_go "github.com/zondax/ledger-go"
+	"github.com/zondax/ledger-go/ledgertest"
+)
+
+func TestConformanceVirtual(t *testing.T) {
+	for _, framed := range []bool{false, true} {
+		framed := framed
+		name := map[bool]string{false: "Direct", true: "Framed"}[framed]
+		t.Run(name, func(t *testing.T) {
+			ledgertest.RunDeviceConformance(t, func() (ledger_go.LedgerDevice, error) {
+				device := ledgertest.NewReferenceApp()
+				device.Framed = framed
+				return device, nil
+			})
+		})
+	}
+}
+
+func TestConformanceMockAdmin(t *testing.T) {
+	t.Run("Virtual", func(t *testing.T) {
+		ledgertest.RunAdminConformance(t, ledgertest.NewAdmin(ledgertest.NewReferenceApp()), 0)
+	})
+
+	t.Run("Canned", func(t *testing.T) {
+		admin := ledgertest.NewAdmin(ledgertest.NewDevice(nil), ledgertest.NewDevice(ledgertest.ReferenceReplies()))
+		ledgertest.RunAdminConformance(t, admin, 1)
+	})
+}
+
+func TestConformanceReplay(t *testing.T) {
+	ledgertest.RunDeviceConformance(t, func() (ledger_go.LedgerDevice, error) {
+		return ledger_go.NewReplayDevice(ledgertest.ReferenceTranscript(), ledger_go.ReplayKeyed), nil
+	})
+}
+
+func TestConformanceWrappers(t *testing.T) {
+	wrappers := map[string]func(device ledger_go.LedgerDevice) ledger_go.LedgerDevice{
+		"Recording": func(device ledger_go.LedgerDevice) ledger_go.LedgerDevice {
+			return ledger_go.NewRecordingDevice(device, io.Discard)
+		},
+		"Faulty": func(device ledger_go.LedgerDevice) ledger_go.LedgerDevice {
+			return ledger_go.NewFaultyDevice(device, ledger_go.FaultConfig{})
+		},
+		"Interactive": func(device ledger_go.LedgerDevice) ledger_go.LedgerDevice {
+			return ledger_go.NewInteractiveDevice(device)
+		},
+	}
+
+	for name, wrap := range wrappers {
+		wrap := wrap
+		t.Run(name, func(t *testing.T) {
+			ledgertest.RunDeviceConformance(t, func() (ledger_go.LedgerDevice, error) {
+				return wrap(ledgertest.NewReferenceApp()), nil
+			})
+		})
+	}
+}
+
+func TestConformanceSpeculos(t *testing.T) {
+	app := ledgertest.NewReferenceApp()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/apdu", func(w http.ResponseWriter, r *http.Request) {
+		var request struct{ Data string }
+		_ = json.NewDecoder(r.Body).Decode(&request)
+		command, _ := hex.DecodeString(request.Data)
+		_ = json.NewEncoder(w).Encode(map[string]string{"data": hex.EncodeToString(app.Process(command))})
+	})
+	mux.HandleFunc("/events", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"events": []}`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	ledgertest.RunAdminConformance(t, ledger_go.NewLedgerAdminSpeculos(ledger_go.WithSpeculosURL(server.URL)), 0)
+}
@@ -0,0 +1,37 @@
+/*******************************************************************************
+*   (c) Zondax AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+********************************************************************************/
+package ledger_go_test
+
+import (
+	"testing"
+
+	"google.golang.org/grpc"
+
+	ledger_go "github.com/zondax/ledger-go"
+	"github.com/zondax/ledger-go/ledgertest"
+)
+
+func TestConformanceZemu(t *testing.T) {
+	address := startZemuStandIn(t, ledgertest.NewReferenceApp())
+	ledgertest.RunAdminConformance(t, ledger_go.NewLedgerAdminZemu(ledger_go.WithZemuAddress(address)), 0)
+}
+
+func TestConformanceZemuServer(t *testing.T) {
+	address, _ := startZemuServer(t, func(server *grpc.Server) {
+		ledger_go.RegisterZemuCommandServer(server, ledger_go.NewZemuServer(ledgertest.NewReferenceApp()))
+	})
+	ledgertest.RunAdminConformance(t, ledger_go.NewLedgerAdminZemu(ledger_go.WithZemuAddress(address)), 0)
+}
@@ -0,0 +1,121 @@
+/*******************************************************************************
+*   (c) Zondax AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+********************************************************************************/
+
+package ledger_go
+
+import (
+	"errors"
+	"fmt"
+)
+
+const (
+	CLABolos = 0xB0
+
+	InsGetAppAndVersion = 0x01
+
+	// DashboardAppName is the name reported when no app is running
+	DashboardAppName = "BOLOS"
+	// DashboardAppNameLegacy is the dashboard name reported by older firmware
+	DashboardAppNameLegacy = "OLOS\x00"
+)
+
+const ErrMsgInvalidResponse = "invalid response"
+
+var ErrInvalidResponse = errors.New(ErrMsgInvalidResponse)
+
+// AppInfo describes the application currently running on the device.
+type AppInfo struct {
+	Name    string
+	Version string
+	Flags   []byte
+}
+
+// IsDashboard reports whether the device is in the dashboard rather than inside an app.
+func (info *AppInfo) IsDashboard() bool {
+	return isDashboardName(info.Name)
+}
+
+func isDashboardName(name string) bool {
+	return name == DashboardAppName || name == DashboardAppNameLegacy
+}
+
+// GetAppAndVersion asks the device which app is running and its version.
+// It is handled by the OS, so it works in the dashboard and inside any app.
+func GetAppAndVersion(device LedgerDevice) (*AppInfo, error) {
+	response, err := device.Exchange([]byte{CLABolos, InsGetAppAndVersion, 0, 0, 0})
+	if err != nil {
+		return nil, err
+	}
+
+	return parseAppAndVersion(response)
+}
+
+func parseAppAndVersion(response []byte) (*AppInfo, error) {
+	const formatID = 0x01
+
+	if len(response) < 1 {
+		return nil, fmt.Errorf("%w: empty app and version reply", ErrInvalidResponse)
+	}
+
+	if response[0] != formatID {
+		return nil, fmt.Errorf("%w: unsupported app and version format %d", ErrInvalidResponse, response[0])
+	}
+
+	offset := 1
+	name, offset, err := readLengthPrefixed(response, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	version, offset, err := readLengthPrefixed(response, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	info := &AppInfo{
+		Name:    string(name),
+		Version: string(version),
+	}
+
+	// Flags are missing in some old firmware versions
+	if offset < len(response) {
+		flags, _, err := readLengthPrefixed(response, offset)
+		if err != nil {
+			return nil, err
+		}
+		info.Flags = flags
+	}
+
+	return info, nil
+}
+
+// readLengthPrefixed reads a field prefixed by a one byte length and returns it
+// together with the offset of the next field.
+func readLengthPrefixed(buffer []byte, offset int) ([]byte, int, error) {
+	if offset >= len(buffer) {
+		return nil, offset, fmt.Errorf("%w: missing field length at offset %d", ErrInvalidResponse, offset)
+	}
+
+	length := int(buffer[offset])
+	offset++
+
+	if offset+length > len(buffer) {
+		return nil, offset, fmt.Errorf("%w: field at offset %d needs %d bytes, %d available",
+			ErrInvalidResponse, offset, length, len(buffer)-offset)
+	}
+
+	return buffer[offset : offset+length], offset + length, nil
+}
@@ -0,0 +1,104 @@
+/*******************************************************************************
+*   (c) Zondax AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+********************************************************************************/
+
+package ledger_go
+
+import (
+	"encoding/hex"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// rawReplies returns a device answering each hex command with its raw hex reply, status word included
+func rawReplies(replies map[string]string) exchangeFunc {
+	return func(command []byte) ([]byte, error) {
+		reply, ok := replies[hex.EncodeToString(command)]
+		if !ok {
+			return nil, fmt.Errorf("unknown command: %x", command)
+		}
+
+		response, err := hex.DecodeString(reply)
+		if err != nil {
+			return nil, err
+		}
+		return ParseResponse(response)
+	}
+}
+
+func TestGetAppAndVersion(t *testing.T) {
+	tests := []struct {
+		name      string
+		reply     string
+		expected  AppInfo
+		dashboard bool
+	}{
+		{
+			name:      "Dashboard",
+			reply:     "0105424f4c4f5305322e312e30010a",
+			expected:  AppInfo{Name: "BOLOS", Version: "2.1.0", Flags: []byte{0x0a}},
+			dashboard: true,
+		},
+		{
+			name:      "LegacyDashboard",
+			reply:     "01054f4c4f530005312e332e31",
+			expected:  AppInfo{Name: "OLOS\x00", Version: "1.3.1"},
+			dashboard: true,
+		},
+		{
+			name:     "App",
+			reply:    "0106436f736d6f7307322e33342e31320100",
+			expected: AppInfo{Name: "Cosmos", Version: "2.34.12", Flags: []byte{0x00}},
+		},
+		{
+			name:     "NoFlags",
+			reply:    "0106436f736d6f7307322e33342e3132",
+			expected: AppInfo{Name: "Cosmos", Version: "2.34.12"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			device := rawReplies(map[string]string{"b001000000": tt.reply + "9000"})
+
+			info, err := GetAppAndVersion(device)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, *info)
+			assert.Equal(t, tt.dashboard, info.IsDashboard())
+		})
+	}
+}
+
+func TestGetAppAndVersionInvalid(t *testing.T) {
+	for _, reply := range []string{"", "02", "0105424f4c", "0105424f4c4f5305322e312e3001"} {
+		device := rawReplies(map[string]string{"b001000000": reply + "9000"})
+
+		_, err := GetAppAndVersion(device)
+		assert.ErrorIs(t, err, ErrInvalidResponse, "reply %q", reply)
+	}
+}
+
+func TestGetAppAndVersionExchangeError(t *testing.T) {
+	_, err := GetAppAndVersion(rawReplies(nil))
+	assert.Error(t, err)
+
+	_, err = GetAppAndVersion(rawReplies(map[string]string{"b001000000": "6e00"}))
+	sw, ok := StatusWord(err)
+	assert.True(t, ok)
+	assert.Equal(t, uint16(0x6E00), sw)
+}
@@ -0,0 +1,107 @@
+/*******************************************************************************
+*   (c) Zondax AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+********************************************************************************/
+
+package ledger_go
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	CLADeviceInfo = 0xE0
+
+	InsGetDeviceInfo = 0x01
+
+	// SpeculosVersion is the SE version reported by the Speculos emulator
+	SpeculosVersion = "Speculos"
+)
+
+// list of known target ids and the corresponding models
+// based on https://github.com/LedgerHQ/ledger-live/blob/develop/libs/ledgerjs/packages/devices/src/index.ts
+var knownTargetIDs = map[uint32]string{
+	0x31100002: "Nano S",
+	0x31100003: "Nano S",
+	0x31100004: "Nano S",
+	0x33000004: "Nano X",
+	0x33100004: "Nano S Plus",
+	0x33200004: "Stax",
+	0x33300004: "Flex",
+}
+
+// DeviceInfo describes the device firmware as reported in the dashboard.
+type DeviceInfo struct {
+	TargetID   uint32
+	SEVersion  string
+	Flags      []byte
+	MCUVersion string
+}
+
+// Model returns the device model derived from the target id, or "Unknown".
+func (info *DeviceInfo) Model() string {
+	if model, ok := knownTargetIDs[info.TargetID]; ok {
+		return model
+	}
+	return "Unknown"
+}
+
+// IsSpeculos reports whether the device is the Speculos emulator.
+func (info *DeviceInfo) IsSpeculos() bool {
+	return info.SEVersion == SpeculosVersion
+}
+
+// GetDeviceInfo queries the firmware information of the device.
+// The command is answered by the dashboard and by many apps.
+func GetDeviceInfo(device LedgerDevice) (*DeviceInfo, error) {
+	response, err := device.Exchange([]byte{CLADeviceInfo, InsGetDeviceInfo, 0, 0, 0})
+	if err != nil {
+		return nil, err
+	}
+
+	return parseDeviceInfo(response)
+}
+
+func parseDeviceInfo(response []byte) (*DeviceInfo, error) {
+	const targetIDSize = 4
+
+	if len(response) < targetIDSize {
+		return nil, fmt.Errorf("%w: device info reply too short (%d bytes)", ErrInvalidResponse, len(response))
+	}
+
+	info := &DeviceInfo{TargetID: codec.Uint32(response)}
+	offset := targetIDSize
+
+	seVersion, offset, err := readLengthPrefixed(response, offset)
+	if err != nil {
+		return nil, err
+	}
+	info.SEVersion = string(seVersion)
+
+	flags, offset, err := readLengthPrefixed(response, offset)
+	if err != nil {
+		return nil, err
+	}
+	info.Flags = flags
+
+	mcuVersion, _, err := readLengthPrefixed(response, offset)
+	if err != nil {
+		return nil, err
+	}
+	// Real devices send a null terminated MCU version
+	info.MCUVersion = strings.TrimRight(string(mcuVersion), "\x00")
+
+	return info, nil
+}
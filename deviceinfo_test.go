@@ -0,0 +1,86 @@
+/*******************************************************************************
+*   (c) Zondax AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+********************************************************************************/
+
+package ledger_go
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDeviceInfo(t *testing.T) {
+	tests := []struct {
+		name     string
+		reply    string
+		expected DeviceInfo
+		model    string
+		speculos bool
+	}{
+		{
+			name:     "Speculos",
+			reply:    "311000040853706563756c6f73000b53706563756c6f734d4355",
+			expected: DeviceInfo{TargetID: 0x31100004, SEVersion: "Speculos", Flags: []byte{}, MCUVersion: "SpeculosMCU"},
+			model:    "Nano S",
+			speculos: true,
+		},
+		{
+			name:     "NanoX",
+			reply:    "3300000405322e322e3304e600000004322e333000",
+			expected: DeviceInfo{TargetID: 0x33000004, SEVersion: "2.2.3", Flags: []byte{0xe6, 0, 0, 0}, MCUVersion: "2.30"},
+			model:    "Nano X",
+		},
+		{
+			name:     "TrailingFields",
+			reply:    "3320000405312e312e3104000000000431302e300001ff",
+			expected: DeviceInfo{TargetID: 0x33200004, SEVersion: "1.1.1", Flags: []byte{0, 0, 0, 0}, MCUVersion: "10.0"},
+			model:    "Stax",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reply, err := hex.DecodeString(tt.reply)
+			require.NoError(t, err)
+
+			info, err := GetDeviceInfo(exchangeFunc(func(command []byte) ([]byte, error) {
+				assert.Equal(t, []byte{0xE0, 0x01, 0, 0, 0}, command)
+				return reply, nil
+			}))
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, *info)
+			assert.Equal(t, tt.model, info.Model())
+			assert.Equal(t, tt.speculos, info.IsSpeculos())
+		})
+	}
+}
+
+func TestParseDeviceInfoInvalid(t *testing.T) {
+	for _, reply := range []string{"", "311000", "3110000408535065", "311000040853706563756c6f73", "311000040853706563756c6f7300"} {
+		data, err := hex.DecodeString(reply)
+		require.NoError(t, err)
+
+		_, err = parseDeviceInfo(data)
+		assert.ErrorIs(t, err, ErrInvalidResponse, "reply %q", reply)
+	}
+}
+
+func TestDeviceInfoUnknownModel(t *testing.T) {
+	info := DeviceInfo{TargetID: 0x12345678}
+	assert.Equal(t, "Unknown", info.Model())
+}
@@ -0,0 +1,167 @@
+/*******************************************************************************
+*   (c) Zondax AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+********************************************************************************/
+
+package ledger_go
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const ErrMsgDeviceBusy = "device busy"
+
+var (
+	ErrDeviceBusy = errors.New(ErrMsgDeviceBusy)
+
+	// errLockHeld is returned by the platform specific lockFile when another
+	// file description already holds the lock.
+	errLockHeld = errors.New("lock held")
+)
+
+// deviceLock is an advisory, cross-process lock on a single device.
+// The lock is tied to an open file, so the OS releases it if the process dies.
+// The PID of the holder is kept in a separate owner file, replaced atomically so
+// that contenders never read a partial PID.
+type deviceLock struct {
+	file      *os.File
+	ownerPath string
+}
+
+// defaultLockDir returns the directory used for device lock files when none is given.
+func defaultLockDir() string {
+	if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" {
+		return filepath.Join(dir, "ledger-go")
+	}
+	return filepath.Join(os.TempDir(), "ledger-go")
+}
+
+const (
+	// lockNamePrefixSize bounds the readable part of lock file names, as device paths
+	// (e.g. macOS IOService paths) can exceed the file name limit
+	lockNamePrefixSize = 32
+	// lockNameHashSize is the number of hash bytes identifying the device
+	lockNameHashSize = 8
+)
+
+// lockFileName turns a device path or serial into a short, safe file name: the end of
+// the sanitized key, for readability, followed by a hash of the whole key.
+func lockFileName(key string) string {
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '.':
+			return r
+		default:
+			return '_'
+		}
+	}, key)
+	if len(name) > lockNamePrefixSize {
+		name = name[len(name)-lockNamePrefixSize:]
+	}
+
+	hash := sha256.Sum256([]byte(key))
+	return name + "-" + hex.EncodeToString(hash[:lockNameHashSize]) + ".lock"
+}
+
+// lockDevice acquires the lock for the device identified by key inside dir.
+// If another process holds it, the returned error wraps ErrDeviceBusy and
+// reports the PID of the holder.
+func lockDevice(dir string, key string) (*deviceLock, error) {
+	if dir == "" {
+		dir = defaultLockDir()
+	}
+
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("could not create lock directory %q: %w", dir, err)
+	}
+
+	path := filepath.Join(dir, lockFileName(key))
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("could not open lock file: %w", err)
+	}
+
+	ownerPath := path + ownerFileSuffix
+	if err := lockFile(file); err != nil {
+		defer file.Close()
+		if errors.Is(err, errLockHeld) {
+			return nil, fmt.Errorf("%w: %s held by PID %d", ErrDeviceBusy, key, readLockOwner(ownerPath))
+		}
+		return nil, fmt.Errorf("could not lock device %s: %w", key, err)
+	}
+
+	// Record the owner so that other processes can report who holds the device
+	writeLockOwner(ownerPath)
+
+	return &deviceLock{file: file, ownerPath: ownerPath}, nil
+}
+
+// ownerFileSuffix is added to the lock file name to get the owner file name
+const ownerFileSuffix = ".owner"
+
+// writeLockOwner stores the PID of this process in the owner file. The file is written
+// aside and renamed over the previous one, so readers see either owner completely.
+// Failures are ignored, the owner is only informative.
+func writeLockOwner(path string) {
+	temp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return
+	}
+
+	_, err = temp.WriteString(strconv.Itoa(os.Getpid()))
+	if closeErr := temp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(temp.Name(), path)
+	}
+	if err != nil {
+		_ = os.Remove(temp.Name())
+	}
+}
+
+// readLockOwner returns the PID stored in the owner file, or 0 if unknown.
+func readLockOwner(path string) int {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0
+	}
+
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil {
+		return 0
+	}
+	return pid
+}
+
+func (lock *deviceLock) release() error {
+	if lock == nil || lock.file == nil {
+		return nil
+	}
+
+	_ = os.Remove(lock.ownerPath)
+	err := unlockFile(lock.file)
+	if closeErr := lock.file.Close(); err == nil {
+		err = closeErr
+	}
+	lock.file = nil
+	return err
+}
@@ -0,0 +1,87 @@
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd || windows
+// +build darwin dragonfly freebsd linux netbsd openbsd windows
+
+/*******************************************************************************
+*   (c) Zondax AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+********************************************************************************/
+
+package ledger_go
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeviceLockExclusive(t *testing.T) {
+	dir := t.TempDir()
+
+	lock, err := lockDevice(dir, "/dev/hidraw0")
+	require.NoError(t, err)
+
+	_, err = lockDevice(dir, "/dev/hidraw0")
+	assert.ErrorIs(t, err, ErrDeviceBusy)
+	assert.Contains(t, err.Error(), fmt.Sprintf("held by PID %d", os.Getpid()))
+
+	// The owner file holds the PID alone, with no temporary file left behind
+	ownerPath := filepath.Join(dir, lockFileName("/dev/hidraw0")+ownerFileSuffix)
+	assert.Equal(t, os.Getpid(), readLockOwner(ownerPath))
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 2)
+
+	// Other devices are not affected
+	other, err := lockDevice(dir, "/dev/hidraw1")
+	require.NoError(t, err)
+	assert.NoError(t, other.release())
+
+	require.NoError(t, lock.release())
+	assert.NoFileExists(t, ownerPath)
+	assert.Equal(t, 0, readLockOwner(ownerPath))
+
+	lock, err = lockDevice(dir, "/dev/hidraw0")
+	require.NoError(t, err)
+	assert.NoError(t, lock.release())
+}
+
+func TestDeviceLockFileName(t *testing.T) {
+	name := lockFileName("/dev/hidraw0")
+	assert.Regexp(t, `^_dev_hidraw0-[0-9a-f]{16}\.lock$`, name)
+	assert.Equal(t, name, lockFileName("/dev/hidraw0"))
+
+	// Keys sanitized to the same text still get their own lock
+	assert.NotEqual(t, lockFileName("1-1:1.0"), lockFileName("1-1_1.0"))
+}
+
+func TestDeviceLockLongPath(t *testing.T) {
+	key := "IOService:/AppleARMPE/arm-io@10F00000/AppleT810xIO/usb-drd1@2280000/" + strings.Repeat("AppleUSBHostPort@01100000/", 20) + "Nano X@01100000"
+	name := lockFileName(key)
+	assert.LessOrEqual(t, len(name), 64)
+	assert.True(t, strings.HasPrefix(name, "ostPort_01100000_Nano_X_01100000-"), name)
+
+	lock, err := lockDevice(t.TempDir(), key)
+	require.NoError(t, err)
+	assert.NoError(t, lock.release())
+}
+
+func TestDeviceLockReleaseNil(t *testing.T) {
+	var lock *deviceLock
+	assert.NoError(t, lock.release())
+}
@@ -0,0 +1,299 @@
+/*******************************************************************************
+*   (c) Zondax AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+********************************************************************************/
+
+package ledger_go
+
+import (
+	"errors"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+const ErrMsgDisconnected = "device disconnected"
+
+var ErrDisconnected = errors.New(ErrMsgDisconnected)
+
+// FaultConfig selects the faults to inject. Rates are probabilities between 0 and 1.
+// Faults are drawn from generators seeded with Seed, one per direction of a stream, so a
+// failing run can be reproduced whatever the scheduling of the reading and writing goroutines.
+type FaultConfig struct {
+	Seed int64
+
+	// Latency delays each exchange or packet read, plus a random duration up to Jitter
+	Latency time.Duration
+	Jitter  time.Duration
+
+	// DisconnectAfter disconnects the device once that many exchanges or packets went through,
+	// when positive. DisconnectRate is the probability to disconnect on each of them.
+	DisconnectAfter int
+	DisconnectRate  float64
+
+	// TruncateRate cuts replies (device level) or packets (frame level) short
+	TruncateRate float64
+
+	// Frame level faults, applied to the packets read from the device
+	DropRate          float64
+	DuplicateRate     float64
+	WrongChannelRate  float64
+	WrongSequenceRate float64
+}
+
+// FaultStats counts the injected faults.
+type FaultStats struct {
+	Delayed       int
+	Disconnected  bool
+	Truncated     int
+	Dropped       int
+	Duplicated    int
+	WrongChannel  int
+	WrongSequence int
+}
+
+// faultInjector holds the state shared by the directions of a wrapper. Random draws
+// use the generator of the calling direction, which is only used from one goroutine.
+type faultInjector struct {
+	mu     sync.Mutex
+	config FaultConfig
+	count  int
+	stats  FaultStats
+}
+
+// writeSeedOffset derives the seed of the write direction of a stream from FaultConfig.Seed
+const writeSeedOffset = 0x5DEECE66D
+
+func newFaultRandom(seed int64) *rand.Rand {
+	return rand.New(rand.NewSource(seed))
+}
+
+// roll reports whether a fault with the given rate happens. Callers hold the lock.
+func (f *faultInjector) roll(random *rand.Rand, rate float64) bool {
+	return rate > 0 && random.Float64() < rate
+}
+
+// check fails once the device is disconnected, without drawing.
+func (f *faultInjector) check() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.stats.Disconnected {
+		return ErrDisconnected
+	}
+	return nil
+}
+
+// deliver accounts for an exchange or packet about to go through and fails, without
+// delivering it, once the device is disconnected.
+func (f *faultInjector) deliver(random *rand.Rand) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if !f.stats.Disconnected {
+		f.count++
+		after := f.config.DisconnectAfter
+		f.stats.Disconnected = (after > 0 && f.count > after) || f.roll(random, f.config.DisconnectRate)
+	}
+
+	if f.stats.Disconnected {
+		return ErrDisconnected
+	}
+	return nil
+}
+
+func (f *faultInjector) delay(random *rand.Rand) {
+	f.mu.Lock()
+	latency := f.config.Latency
+	if f.config.Jitter > 0 {
+		latency += time.Duration(random.Int63n(int64(f.config.Jitter)))
+	}
+	if latency > 0 {
+		f.stats.Delayed++
+	}
+	f.mu.Unlock()
+
+	time.Sleep(latency)
+}
+
+// truncate returns a random prefix of data when the truncation fault happens.
+func (f *faultInjector) truncate(random *rand.Rand, data []byte) []byte {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if len(data) == 0 || !f.roll(random, f.config.TruncateRate) {
+		return data
+	}
+	f.stats.Truncated++
+	return data[:random.Intn(len(data))]
+}
+
+func (f *faultInjector) snapshot() FaultStats {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.stats
+}
+
+// FaultyDevice is a LedgerDevice injecting latency, truncated replies and disconnections.
+type FaultyDevice struct {
+	device LedgerDevice
+	faults *faultInjector
+	random *rand.Rand
+}
+
+func NewFaultyDevice(device LedgerDevice, config FaultConfig) *FaultyDevice {
+	return &FaultyDevice{
+		device: device,
+		faults: &faultInjector{config: config},
+		random: newFaultRandom(config.Seed),
+	}
+}
+
+func (d *FaultyDevice) Exchange(command []byte) ([]byte, error) {
+	if err := d.faults.deliver(d.random); err != nil {
+		return nil, err
+	}
+	d.faults.delay(d.random)
+
+	response, err := d.device.Exchange(command)
+
+	raw, ok := rawResponse(response, err)
+	if !ok {
+		return response, err
+	}
+	truncated := d.faults.truncate(d.random, raw)
+	if len(truncated) == len(raw) {
+		return response, err
+	}
+	return ParseResponse(truncated)
+}
+
+// Stats returns the faults injected so far.
+func (d *FaultyDevice) Stats() FaultStats {
+	return d.faults.snapshot()
+}
+
+func (d *FaultyDevice) Close() error {
+	return d.device.Close()
+}
+
+// PacketStream is a raw HID packet stream, such as a *hid.Device.
+type PacketStream interface {
+	Read(buffer []byte) (int, error)
+	Write(buffer []byte) (int, error)
+	Close() error
+}
+
+// FaultyStream is a PacketStream corrupting the packets read from the device.
+// Writes are only affected by latency and disconnections. DisconnectAfter counts
+// the packets actually delivered in both directions, dropped ones excluded.
+type FaultyStream struct {
+	stream  PacketStream
+	faults  *faultInjector
+	reads   *rand.Rand
+	writes  *rand.Rand
+	pending [][]byte
+}
+
+func NewFaultyStream(stream PacketStream, config FaultConfig) *FaultyStream {
+	return &FaultyStream{
+		stream: stream,
+		faults: &faultInjector{config: config},
+		reads:  newFaultRandom(config.Seed),
+		writes: newFaultRandom(config.Seed + writeSeedOffset),
+	}
+}
+
+func (s *FaultyStream) Read(buffer []byte) (int, error) {
+	if len(s.pending) > 0 {
+		if err := s.faults.deliver(s.reads); err != nil {
+			return 0, err
+		}
+		n := copy(buffer, s.pending[0])
+		s.pending = s.pending[1:]
+		return n, nil
+	}
+
+	for {
+		if err := s.faults.check(); err != nil {
+			return 0, err
+		}
+
+		n, err := s.stream.Read(buffer)
+		if err != nil || n == 0 {
+			return n, err
+		}
+		s.faults.delay(s.reads)
+
+		if n, ok := s.corrupt(buffer[:n]); ok {
+			if err := s.faults.deliver(s.reads); err != nil {
+				return 0, err
+			}
+			return n, nil
+		}
+	}
+}
+
+// corrupt applies the frame faults to packet in place and returns its new length,
+// or false when the packet is dropped.
+func (s *FaultyStream) corrupt(packet []byte) (int, bool) {
+	f, random := s.faults, s.reads
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.roll(random, f.config.DropRate) {
+		f.stats.Dropped++
+		return 0, false
+	}
+
+	if len(packet) >= 2 && f.roll(random, f.config.WrongChannelRate) {
+		f.stats.WrongChannel++
+		codec.PutUint16(packet, ^codec.Uint16(packet))
+	}
+
+	if len(packet) >= 5 && f.roll(random, f.config.WrongSequenceRate) {
+		f.stats.WrongSequence++
+		codec.PutUint16(packet[3:], codec.Uint16(packet[3:])+1)
+	}
+
+	n := len(packet)
+	if n > 0 && f.roll(random, f.config.TruncateRate) {
+		f.stats.Truncated++
+		n = random.Intn(n)
+	}
+
+	if f.roll(random, f.config.DuplicateRate) {
+		f.stats.Duplicated++
+		s.pending = append(s.pending, append([]byte{}, packet[:n]...))
+	}
+
+	return n, true
+}
+
+func (s *FaultyStream) Write(buffer []byte) (int, error) {
+	if err := s.faults.deliver(s.writes); err != nil {
+		return 0, err
+	}
+	s.faults.delay(s.writes)
+	return s.stream.Write(buffer)
+}
+
+// Stats returns the faults injected so far.
+func (s *FaultyStream) Stats() FaultStats {
+	return s.faults.snapshot()
+}
+
+func (s *FaultyStream) Close() error {
+	return s.stream.Close()
+}
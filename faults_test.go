@@ -0,0 +1,220 @@
+/*******************************************************************************
+*   (c) Zondax AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+********************************************************************************/
+
+package ledger_go
+
+import (
+	"bytes"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// packetQueue is a PacketStream reading back queued packets
+type packetQueue struct {
+	packets [][]byte
+	written []byte
+}
+
+func newPacketQueue(t *testing.T, message []byte) *packetQueue {
+	frames, err := WrapCommandAPDU(Channel, message, PacketSize)
+	require.NoError(t, err)
+
+	queue := &packetQueue{}
+	for len(frames) > 0 {
+		queue.packets = append(queue.packets, frames[:PacketSize])
+		frames = frames[PacketSize:]
+	}
+	return queue
+}
+
+func (q *packetQueue) Read(buffer []byte) (int, error) {
+	if len(q.packets) == 0 {
+		return 0, io.EOF
+	}
+	n := copy(buffer, q.packets[0])
+	q.packets = q.packets[1:]
+	return n, nil
+}
+
+func (q *packetQueue) Write(buffer []byte) (int, error) {
+	q.written = append(q.written, buffer...)
+	return len(buffer), nil
+}
+
+func (q *packetQueue) Close() error {
+	return nil
+}
+
+// readPackets reads stream until it fails and unwraps the packets
+func readPackets(stream PacketStream) ([]byte, error) {
+	pipe := make(chan []byte, 64)
+	for {
+		buffer := make([]byte, PacketSize)
+		n, err := stream.Read(buffer)
+		if err != nil {
+			break
+		}
+		pipe <- buffer[:n]
+	}
+	close(pipe)
+	return UnwrapResponseAPDU(Channel, pipe, PacketSize)
+}
+
+func TestFaultyDeviceDisconnect(t *testing.T) {
+	device := NewFaultyDevice(newVirtualApp(), FaultConfig{DisconnectAfter: 2})
+	command := []byte{virtualCLA, InsGetVersion, 0x00, 0x00, 0x00}
+
+	for i := 0; i < 2; i++ {
+		_, err := device.Exchange(command)
+		require.NoError(t, err)
+	}
+
+	for i := 0; i < 2; i++ {
+		_, err := device.Exchange(command)
+		assert.ErrorIs(t, err, ErrDisconnected)
+	}
+	assert.True(t, device.Stats().Disconnected)
+}
+
+func TestFaultyDeviceLatency(t *testing.T) {
+	device := NewFaultyDevice(newVirtualApp(), FaultConfig{Latency: 20 * time.Millisecond, Jitter: 10 * time.Millisecond})
+
+	start := time.Now()
+	_, err := device.Exchange([]byte{virtualCLA, InsGetVersion, 0x00, 0x00, 0x00})
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, time.Since(start), 20*time.Millisecond)
+	assert.Equal(t, 1, device.Stats().Delayed)
+}
+
+func TestFaultyDeviceTruncateIsDeterministic(t *testing.T) {
+	run := func(seed int64) []string {
+		device := NewFaultyDevice(newVirtualApp(), FaultConfig{Seed: seed, TruncateRate: 0.5})
+		var outcomes []string
+		for i := 0; i < 20; i++ {
+			response, err := device.Exchange([]byte{virtualCLA, InsGetVersion, 0x00, 0x00, 0x00})
+			outcome := "ok"
+			if err != nil {
+				outcome = err.Error()
+			} else if len(response) != 12 {
+				outcome = "short"
+			}
+			outcomes = append(outcomes, outcome)
+		}
+		return outcomes
+	}
+
+	first := run(42)
+	assert.Equal(t, first, run(42))
+	assert.Contains(t, first, "ok")
+
+	truncated := 0
+	for _, outcome := range first {
+		if outcome != "ok" {
+			truncated++
+		}
+	}
+	assert.Greater(t, truncated, 0)
+}
+
+func TestFaultyStreamPassThrough(t *testing.T) {
+	message := bytes.Repeat([]byte{0xab}, 200)
+	stream := NewFaultyStream(newPacketQueue(t, message), FaultConfig{})
+
+	received, err := readPackets(stream)
+	require.NoError(t, err)
+	assert.Equal(t, message, received)
+
+	_, err = stream.Write([]byte{0x01})
+	assert.NoError(t, err)
+}
+
+func TestFaultyStreamFrames(t *testing.T) {
+	message := bytes.Repeat([]byte{0xab}, 200)
+
+	tests := []struct {
+		name     string
+		config   FaultConfig
+		expected error
+	}{
+		{"WrongChannel", FaultConfig{WrongChannelRate: 1}, ErrInvalidChannel},
+		{"Dropped", FaultConfig{DropRate: 1}, ErrIncompleteResponse},
+		{"Disconnected", FaultConfig{DisconnectAfter: 2}, ErrIncompleteResponse},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stream := NewFaultyStream(newPacketQueue(t, message), tt.config)
+			_, err := readPackets(stream)
+			assert.ErrorIs(t, err, tt.expected)
+		})
+	}
+
+	stream := NewFaultyStream(newPacketQueue(t, message), FaultConfig{WrongSequenceRate: 1})
+	_, err := readPackets(stream)
+	assert.ErrorContains(t, err, "wrong sequenceIdx")
+
+	stream = NewFaultyStream(newPacketQueue(t, message), FaultConfig{DuplicateRate: 1})
+	_, err = readPackets(stream)
+	assert.ErrorContains(t, err, "wrong sequenceIdx")
+	assert.Equal(t, 4, stream.Stats().Duplicated)
+}
+
+func TestFaultyStreamSeedIgnoresWrites(t *testing.T) {
+	message := bytes.Repeat([]byte{0xab}, 1000)
+	config := FaultConfig{Seed: 7, DropRate: 0.2, TruncateRate: 0.2, WrongSequenceRate: 0.2, DuplicateRate: 0.2}
+
+	run := func(writes int) ([][]byte, FaultStats) {
+		stream := NewFaultyStream(newPacketQueue(t, message), config)
+		var packets [][]byte
+		for {
+			for i := 0; i < writes; i++ {
+				_, err := stream.Write([]byte{0x01})
+				require.NoError(t, err)
+			}
+			buffer := make([]byte, PacketSize)
+			n, err := stream.Read(buffer)
+			if err != nil {
+				break
+			}
+			packets = append(packets, buffer[:n])
+		}
+		return packets, stream.Stats()
+	}
+
+	packets, stats := run(0)
+	interleaved, interleavedStats := run(3)
+	assert.Equal(t, packets, interleaved)
+	assert.Equal(t, stats, interleavedStats)
+	assert.Greater(t, stats.Dropped, 0)
+}
+
+func TestFaultyStreamDisconnectCountsDelivered(t *testing.T) {
+	// Dropped packets are never delivered, so the reader sees the end of the stream
+	stream := NewFaultyStream(newPacketQueue(t, bytes.Repeat([]byte{0xab}, 500)), FaultConfig{DisconnectAfter: 1, DropRate: 1})
+
+	_, err := stream.Read(make([]byte, PacketSize))
+	assert.ErrorIs(t, err, io.EOF)
+	assert.False(t, stream.Stats().Disconnected)
+
+	_, err = stream.Write([]byte{0x01})
+	require.NoError(t, err)
+	_, err = stream.Write([]byte{0x01})
+	assert.ErrorIs(t, err, ErrDisconnected)
+}
@@ -0,0 +1,232 @@
+/*******************************************************************************
+*   (c) Zondax AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+********************************************************************************/
+
+package ledger_go
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Status words used by apps when the user rejects a request on the device
+const (
+	SwConditionsNotSatisfied = 0x6985
+	SwCommandNotAllowed      = 0x6986
+)
+
+const (
+	// DefaultPromptDelay is how long an interactive exchange may take before
+	// the device is assumed to be waiting for the user
+	DefaultPromptDelay = 500 * time.Millisecond
+
+	ErrMsgExchangeTimeout = "exchange timeout"
+)
+
+var ErrExchangeTimeout = errors.New(ErrMsgExchangeTimeout)
+
+// InteractionEventType identifies the progress of an interactive exchange.
+type InteractionEventType int
+
+const (
+	// EventWaitingForUser is emitted when the device has not answered within the prompt delay
+	EventWaitingForUser InteractionEventType = iota
+	// EventUserApproved is emitted when the device answers with success
+	EventUserApproved
+	// EventUserRejected is emitted when the device answers with a rejection status word
+	EventUserRejected
+	// EventTimeout is emitted when the interactive timeout expires
+	EventTimeout
+)
+
+func (t InteractionEventType) String() string {
+	switch t {
+	case EventWaitingForUser:
+		return "waiting for user confirmation"
+	case EventUserApproved:
+		return "approved"
+	case EventUserRejected:
+		return "rejected"
+	case EventTimeout:
+		return "timeout"
+	default:
+		return fmt.Sprintf("InteractionEventType(%d)", int(t))
+	}
+}
+
+// InteractionEvent reports the progress of an interactive exchange.
+type InteractionEvent struct {
+	Type    InteractionEventType
+	Command []byte
+	Elapsed time.Duration
+}
+
+// InteractiveExchanger is implemented by devices that handle exchanges which
+// need a confirmation on the device, such as signing or showing an address.
+type InteractiveExchanger interface {
+	ExchangeInteractive(command []byte) ([]byte, error)
+}
+
+// InteractiveDevice wraps a device, bounding regular exchanges by Timeout while
+// exchanges marked as interactive use InteractiveTimeout and report progress.
+// A zero timeout means no limit. When a timeout expires the underlying exchange
+// keeps running and the next exchanges wait for it to end, so they never get its
+// reply; closing the device is the way to abort it.
+type InteractiveDevice struct {
+	device LedgerDevice
+	// busy is held while an exchange runs on device, created on first use
+	busy     chan struct{}
+	busyOnce sync.Once
+
+	Timeout            time.Duration
+	InteractiveTimeout time.Duration
+	PromptDelay        time.Duration
+
+	// OnEvent, when set, is called with the progress of interactive exchanges
+	OnEvent func(InteractionEvent)
+}
+
+// NewInteractiveDevice wraps device with no timeouts and the default prompt delay.
+func NewInteractiveDevice(device LedgerDevice) *InteractiveDevice {
+	return &InteractiveDevice{
+		device:      device,
+		PromptDelay: DefaultPromptDelay,
+	}
+}
+
+// Exchange sends a command that is not expected to wait for the user.
+func (d *InteractiveDevice) Exchange(command []byte) ([]byte, error) {
+	return d.exchange(command, d.Timeout, nil)
+}
+
+// ExchangeInteractive sends a command that waits for a confirmation on the device.
+// A rejection by the user is reported as ErrUserRefused.
+func (d *InteractiveDevice) ExchangeInteractive(command []byte) ([]byte, error) {
+	start := time.Now()
+	emit := func(eventType InteractionEventType) {
+		if d.OnEvent != nil {
+			d.OnEvent(InteractionEvent{Type: eventType, Command: command, Elapsed: time.Since(start)})
+		}
+	}
+
+	response, err := d.exchange(command, d.InteractiveTimeout, func() { emit(EventWaitingForUser) })
+	switch {
+	case errors.Is(err, ErrExchangeTimeout):
+		emit(EventTimeout)
+	case err != nil:
+		err = rejectionError(err)
+		if errors.Is(err, ErrUserRefused) {
+			emit(EventUserRejected)
+		}
+	default:
+		emit(EventUserApproved)
+	}
+
+	return response, err
+}
+
+type exchangeResult struct {
+	response []byte
+	err      error
+}
+
+// exchange runs the command bounded by timeout, calling onPrompt once the prompt delay expires.
+// The timeout includes waiting for a previous exchange that timed out to end.
+func (d *InteractiveDevice) exchange(command []byte, timeout time.Duration, onPrompt func()) ([]byte, error) {
+	var timeoutC, promptC <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		timeoutC = timer.C
+	}
+	if onPrompt != nil {
+		timer := time.NewTimer(d.PromptDelay)
+		defer timer.Stop()
+		promptC = timer.C
+	}
+
+	busy := d.busyChannel()
+	for acquired := false; !acquired; {
+		select {
+		case busy <- struct{}{}:
+			acquired = true
+		case <-promptC:
+			promptC = nil
+			onPrompt()
+		case <-timeoutC:
+			return nil, fmt.Errorf("%w: previous exchange still running after %s", ErrExchangeTimeout, timeout)
+		}
+	}
+
+	done := make(chan exchangeResult, 1)
+	go func() {
+		defer func() { <-busy }()
+		response, err := d.device.Exchange(command)
+		done <- exchangeResult{response: response, err: err}
+	}()
+
+	for {
+		select {
+		case result := <-done:
+			return result.response, result.err
+		case <-promptC:
+			promptC = nil
+			onPrompt()
+		case <-timeoutC:
+			return nil, fmt.Errorf("%w: no reply after %s", ErrExchangeTimeout, timeout)
+		}
+	}
+}
+
+func (d *InteractiveDevice) busyChannel() chan struct{} {
+	d.busyOnce.Do(func() {
+		d.busy = make(chan struct{}, 1)
+	})
+	return d.busy
+}
+
+// Close closes the underlying device.
+func (d *InteractiveDevice) Close() error {
+	return d.device.Close()
+}
+
+// ExchangeInteractive sends a command that waits for a confirmation on the device,
+// using the device's own handling when it implements InteractiveExchanger.
+// A rejection by the user is reported as ErrUserRefused.
+func ExchangeInteractive(device LedgerDevice, command []byte) ([]byte, error) {
+	if interactive, ok := device.(InteractiveExchanger); ok {
+		return interactive.ExchangeInteractive(command)
+	}
+
+	response, err := device.Exchange(command)
+	return response, rejectionError(err)
+}
+
+// rejectionError maps the status words used for a rejection on the device to ErrUserRefused.
+func rejectionError(err error) error {
+	sw, ok := StatusWord(err)
+	if !ok {
+		return err
+	}
+
+	switch sw {
+	case SwUserRefused, SwConditionsNotSatisfied, SwCommandNotAllowed:
+		return fmt.Errorf("%w: %w", ErrUserRefused, err)
+	default:
+		return err
+	}
+}
@@ -0,0 +1,200 @@
+/*******************************************************************************
+*   (c) Zondax AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+********************************************************************************/
+
+package ledger_go
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// slowDevice answers after delay with the given status word
+func slowDevice(delay time.Duration, sw uint16) exchangeFunc {
+	return func([]byte) ([]byte, error) {
+		time.Sleep(delay)
+		if sw != 0x9000 {
+			return nil, &APDUError{Code: sw}
+		}
+		return []byte{0xAA}, nil
+	}
+}
+
+type eventRecorder struct {
+	mu     sync.Mutex
+	events []InteractionEventType
+}
+
+func (r *eventRecorder) record(event InteractionEvent) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, event.Type)
+}
+
+func (r *eventRecorder) types() []InteractionEventType {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.events
+}
+
+func TestInteractiveDeviceApproved(t *testing.T) {
+	recorder := &eventRecorder{}
+	device := NewInteractiveDevice(slowDevice(100*time.Millisecond, 0x9000))
+	device.PromptDelay = 10 * time.Millisecond
+	device.Timeout = 20 * time.Millisecond
+	device.OnEvent = recorder.record
+
+	response, err := device.ExchangeInteractive([]byte{0x55, 0x02, 0x02, 0x00, 0x00})
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0xAA}, response)
+	assert.Equal(t, []InteractionEventType{EventWaitingForUser, EventUserApproved}, recorder.types())
+}
+
+func TestInteractiveDeviceFastReply(t *testing.T) {
+	recorder := &eventRecorder{}
+	device := NewInteractiveDevice(slowDevice(0, 0x9000))
+	device.PromptDelay = time.Second
+	device.OnEvent = recorder.record
+
+	_, err := device.ExchangeInteractive([]byte{0x55, 0x02, 0x02, 0x00, 0x00})
+	require.NoError(t, err)
+	assert.Equal(t, []InteractionEventType{EventUserApproved}, recorder.types())
+}
+
+func TestInteractiveDeviceRejected(t *testing.T) {
+	for _, sw := range []uint16{SwCommandNotAllowed, SwConditionsNotSatisfied, SwUserRefused} {
+		recorder := &eventRecorder{}
+		device := NewInteractiveDevice(slowDevice(0, sw))
+		device.OnEvent = recorder.record
+
+		_, err := device.ExchangeInteractive([]byte{0x55, 0x02, 0x02, 0x00, 0x00})
+		assert.ErrorIs(t, err, ErrUserRefused)
+		assert.Equal(t, []InteractionEventType{EventUserRejected}, recorder.types())
+	}
+
+	device := NewInteractiveDevice(slowDevice(0, 0x6E00))
+	_, err := device.ExchangeInteractive([]byte{0x55, 0x02, 0x02, 0x00, 0x00})
+	assert.EqualError(t, err, ErrorMessage(0x6E00))
+}
+
+func TestInteractiveDeviceTimeouts(t *testing.T) {
+	recorder := &eventRecorder{}
+	device := NewInteractiveDevice(slowDevice(200*time.Millisecond, 0x9000))
+	device.Timeout = 20 * time.Millisecond
+	device.InteractiveTimeout = 50 * time.Millisecond
+	device.PromptDelay = 10 * time.Millisecond
+	device.OnEvent = recorder.record
+
+	_, err := device.Exchange([]byte{0x55, 0x00, 0x00, 0x00, 0x00})
+	assert.ErrorIs(t, err, ErrExchangeTimeout)
+
+	_, err = device.ExchangeInteractive([]byte{0x55, 0x02, 0x02, 0x00, 0x00})
+	assert.ErrorIs(t, err, ErrExchangeTimeout)
+	assert.Equal(t, []InteractionEventType{EventWaitingForUser, EventTimeout}, recorder.types())
+
+	// Regular exchanges are not bounded when no timeout is configured
+	device.Timeout = 0
+	_, err = device.Exchange([]byte{0x55, 0x00, 0x00, 0x00, 0x00})
+	assert.NoError(t, err)
+}
+
+func TestInteractiveDeviceAfterTimeout(t *testing.T) {
+	// The device echoes the instruction, the first exchange is slower than the timeout
+	var calls int
+	var mu sync.Mutex
+	device := NewInteractiveDevice(exchangeFunc(func(command []byte) ([]byte, error) {
+		mu.Lock()
+		calls++
+		first := calls == 1
+		mu.Unlock()
+		if first {
+			time.Sleep(100 * time.Millisecond)
+		}
+		return []byte{command[1]}, nil
+	}))
+	device.Timeout = 50 * time.Millisecond
+
+	_, err := device.Exchange([]byte{0x55, 0x01, 0x00, 0x00, 0x00})
+	assert.ErrorIs(t, err, ErrExchangeTimeout)
+
+	// Waits for the first exchange to end instead of overlapping it
+	device.Timeout = time.Second
+	response, err := device.Exchange([]byte{0x55, 0x02, 0x00, 0x00, 0x00})
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0x02}, response)
+
+	// A previous exchange that outlasts the timeout makes the next one time out too
+	device.Timeout = 10 * time.Millisecond
+	mu.Lock()
+	calls = 0
+	mu.Unlock()
+	_, err = device.Exchange([]byte{0x55, 0x03, 0x00, 0x00, 0x00})
+	assert.ErrorIs(t, err, ErrExchangeTimeout)
+	_, err = device.Exchange([]byte{0x55, 0x04, 0x00, 0x00, 0x00})
+	assert.ErrorContains(t, err, "previous exchange still running")
+}
+
+func TestInteractiveDeviceLiteral(t *testing.T) {
+	device := &InteractiveDevice{device: slowDevice(0, 0x9000), Timeout: time.Second}
+
+	for i := 0; i < 2; i++ {
+		response, err := device.Exchange([]byte{0x55, 0x00, 0x00, 0x00, 0x00})
+		require.NoError(t, err)
+		assert.Equal(t, []byte{0xAA}, response)
+	}
+}
+
+func TestExchangeInteractivePlainDevice(t *testing.T) {
+	_, err := ExchangeInteractive(slowDevice(0, SwCommandNotAllowed), []byte{0x55, 0x02, 0x02, 0x00, 0x00})
+	assert.ErrorIs(t, err, ErrUserRefused)
+
+	sw, ok := StatusWord(err)
+	assert.True(t, ok)
+	assert.Equal(t, uint16(SwCommandNotAllowed), sw)
+}
+
+func TestAppClientInteractive(t *testing.T) {
+	recorder := &eventRecorder{}
+	device := NewInteractiveDevice(exchangeFunc(func(command []byte) ([]byte, error) {
+		if command[1] == InsSign && command[2] == PayloadLast {
+			return nil, &APDUError{Code: SwCommandNotAllowed}
+		}
+		return []byte{0x01, 0xAA}, nil
+	}))
+	device.OnEvent = recorder.record
+
+	client := NewAppClient(device, AppConfig{CLA: 0x55})
+
+	_, err := client.GetAddress(testPath, false)
+	require.NoError(t, err)
+	assert.Empty(t, recorder.types())
+
+	_, err = client.GetAddress(testPath, true)
+	require.NoError(t, err)
+	assert.Equal(t, []InteractionEventType{EventUserApproved}, recorder.types())
+
+	_, err = client.Sign(testPath, []byte{1, 2, 3})
+	assert.ErrorIs(t, err, ErrUserRefused)
+	assert.Equal(t, []InteractionEventType{EventUserApproved, EventUserRejected}, recorder.types())
+}
+
+func TestInteractionEventTypeString(t *testing.T) {
+	assert.Equal(t, "waiting for user confirmation", EventWaitingForUser.String())
+	assert.Equal(t, "InteractionEventType(42)", InteractionEventType(42).String())
+}
@@ -16,6 +16,23 @@
 
 package ledger_go
 
+import "errors"
+
+const (
+	ErrMsgDeviceNotFound      = "device not found"
+	ErrMsgEmulatorUnreachable = "emulator unreachable"
+	ErrMsgTextNotFound        = "text not found on screen"
+)
+
+var (
+	// ErrDeviceNotFound is returned by Connect when there is no device at the requested index.
+	ErrDeviceNotFound = errors.New(ErrMsgDeviceNotFound)
+	// ErrEmulatorUnreachable is returned when an emulator cannot be reached or is not serving.
+	ErrEmulatorUnreachable = errors.New(ErrMsgEmulatorUnreachable)
+	// ErrTextNotFound is returned when waiting for text on an emulator screen times out.
+	ErrTextNotFound = errors.New(ErrMsgTextNotFound)
+)
+
 // LedgerAdmin defines the interface for managing Ledger devices.
 type LedgerAdmin interface {
 	CountDevices() int
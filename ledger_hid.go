@@ -1,6 +1,3 @@
-//go:build !ledger_mock && !ledger_zemu
-// +build !ledger_mock,!ledger_zemu
-
 /*******************************************************************************
 *   (c) Zondax AG
 *
@@ -24,6 +21,7 @@ import (
 	"fmt"
 	"log"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/zondax/hid"
@@ -32,16 +30,28 @@ import (
 const (
 	VendorLedger         = 0x2c97
 	UsagePageLedgerNanoS = 0xffa0
-	Channel              = 0x0101
-	PacketSize           = 64
 )
 
-type LedgerAdminHID struct{}
+type LedgerAdminHID struct {
+	backend hidBackend
+
+	// exclusive enables an advisory lock per device, held while connected
+	exclusive bool
+	lockDir   string
+
+	// faults, when set, are injected in the packet stream of every connection
+	faults *FaultConfig
+}
 
 type LedgerDeviceHID struct {
-	device      *hid.Device
+	device      hidDevice
 	readCo      *sync.Once
 	readChannel chan []byte
+	lock        *deviceLock
+
+	// exchangeMu serializes exchanges, as their packets share the same stream
+	exchangeMu sync.Mutex
+	closed     atomic.Bool
 }
 
 // list of supported product ids as well as their corresponding interfaces
@@ -54,12 +64,67 @@ var supportedLedgerProductID = map[uint8]int{
 	0x70: 0, // Ledger Flex
 }
 
-func NewLedgerAdmin() LedgerAdmin {
-	return &LedgerAdminHID{}
+// models of the supported product ids, keyed as in supportedLedgerProductID
+var ledgerProductModels = map[uint8]string{
+	0x40: "Nano X",
+	0x10: "Nano S",
+	0x50: "Nano S Plus",
+	0x60: "Stax",
+	0x70: "Flex",
+}
+
+// DeviceDescriptor describes a Ledger device found on USB.
+type DeviceDescriptor struct {
+	// Index is the index to pass to Connect
+	Index        int
+	Path         string
+	Model        string
+	VendorID     uint16
+	ProductID    uint16
+	Interface    int
+	UsagePage    uint16
+	Serial       string
+	Manufacturer string
+	Product      string
+}
+
+// NewLedgerAdminHID returns an admin for the Ledger devices connected over USB.
+func NewLedgerAdminHID() *LedgerAdminHID {
+	return &LedgerAdminHID{backend: systemHID{}}
+}
+
+// NewLedgerAdminExclusive returns an admin whose connections hold a cross-process
+// advisory lock on the device until Close is called. Lock files are kept in lockDir,
+// or in a "ledger-go" directory under the runtime/temp dir when lockDir is empty.
+// Connecting to a device locked by another process fails with ErrDeviceBusy, and
+// connecting fails on platforms without file locking.
+func NewLedgerAdminExclusive(lockDir string) LedgerAdmin {
+	return &LedgerAdminHID{
+		backend:   systemHID{},
+		exclusive: true,
+		lockDir:   lockDir,
+	}
+}
+
+// NewLedgerAdminFaulty returns an admin injecting faults in the HID packet stream of
+// every connection, to reproduce transport failures with real devices.
+func NewLedgerAdminFaulty(config FaultConfig) LedgerAdmin {
+	return &LedgerAdminHID{
+		backend: systemHID{},
+		faults:  &config,
+	}
+}
+
+// hidAPI returns the backend used to reach devices, defaulting to the OS one.
+func (admin *LedgerAdminHID) hidAPI() hidBackend {
+	if admin.backend == nil {
+		return systemHID{}
+	}
+	return admin.backend
 }
 
 func (admin *LedgerAdminHID) ListDevices() ([]string, error) {
-	devices := hid.Enumerate(0, 0)
+	devices := admin.hidAPI().Enumerate(0, 0)
 	if len(devices) == 0 {
 		log.Println("No devices. Ledger LOCKED OR Other Program/Web Browser may have control of device.")
 	}
@@ -97,7 +162,7 @@ func isLedgerDevice(d hid.DeviceInfo) bool {
 }
 
 func (admin *LedgerAdminHID) CountDevices() int {
-	devices := hid.Enumerate(0, 0)
+	devices := admin.hidAPI().Enumerate(0, 0)
 
 	count := 0
 	for _, d := range devices {
@@ -109,7 +174,35 @@ func (admin *LedgerAdminHID) CountDevices() int {
 	return count
 }
 
-func newDevice(dev *hid.Device) *LedgerDeviceHID {
+// Descriptors returns the connected Ledger devices, in the order used by Connect.
+func (admin *LedgerAdminHID) Descriptors() []DeviceDescriptor {
+	descriptors := []DeviceDescriptor{}
+	for _, d := range admin.hidAPI().Enumerate(VendorLedger, 0) {
+		if !isLedgerDevice(d) {
+			continue
+		}
+
+		model, ok := ledgerProductModels[uint8(d.ProductID>>8)]
+		if !ok {
+			model = "Unknown"
+		}
+		descriptors = append(descriptors, DeviceDescriptor{
+			Index:        len(descriptors),
+			Path:         d.Path,
+			Model:        model,
+			VendorID:     d.VendorID,
+			ProductID:    d.ProductID,
+			Interface:    d.Interface,
+			UsagePage:    d.UsagePage,
+			Serial:       d.Serial,
+			Manufacturer: d.Manufacturer,
+			Product:      d.Product,
+		})
+	}
+	return descriptors
+}
+
+func newDevice(dev hidDevice) *LedgerDeviceHID {
 	return &LedgerDeviceHID{
 		device:      dev,
 		readCo:      new(sync.Once),
@@ -118,17 +211,31 @@ func newDevice(dev *hid.Device) *LedgerDeviceHID {
 }
 
 func (admin *LedgerAdminHID) Connect(requiredIndex int) (LedgerDevice, error) {
-	devices := hid.Enumerate(VendorLedger, 0)
+	devices := admin.hidAPI().Enumerate(VendorLedger, 0)
 
 	currentIndex := 0
 	for _, d := range devices {
 		if isLedgerDevice(d) {
 			if currentIndex == requiredIndex {
-				device, err := d.Open()
+				var lock *deviceLock
+				if admin.exclusive {
+					var err error
+					lock, err = lockDevice(admin.lockDir, deviceLockKey(d))
+					if err != nil {
+						return nil, err
+					}
+				}
+
+				device, err := admin.hidAPI().Open(d)
 				if err != nil {
+					_ = lock.release()
 					return nil, err
 				}
+				if admin.faults != nil {
+					device = faultyHIDDevice{NewFaultyStream(device, *admin.faults)}
+				}
 				deviceHID := newDevice(device)
+				deviceHID.lock = lock
 				return deviceHID, nil
 			}
 			currentIndex++
@@ -138,7 +245,16 @@ func (admin *LedgerAdminHID) Connect(requiredIndex int) (LedgerDevice, error) {
 		}
 	}
 
-	return nil, fmt.Errorf("LedgerHID device (idx %d) not found: device may be locked or in use by another application", requiredIndex)
+	return nil, fmt.Errorf("LedgerHID %w (idx %d): device may be locked or in use by another application", ErrDeviceNotFound, requiredIndex)
+}
+
+// deviceLockKey identifies a device for locking purposes.
+// Ledger devices may share the same serial, so the path is preferred.
+func deviceLockKey(d hid.DeviceInfo) string {
+	if d.Path != "" {
+		return d.Path
+	}
+	return d.Serial
 }
 
 func (ledger *LedgerDeviceHID) write(buffer []byte) (int, error) {
@@ -173,6 +289,11 @@ func (ledger *LedgerDeviceHID) readThread() {
 		buffer := make([]byte, PacketSize)
 		readBytes, err := ledger.device.Read(buffer)
 
+		// The device was closed, nothing else will arrive
+		if errors.Is(err, hid.ErrDeviceClosed) {
+			return
+		}
+
 		// Check for HID Read Error (May occur even during normal runtime)
 		if err != nil {
 			continue
@@ -208,7 +329,11 @@ func (ledger *LedgerDeviceHID) drainRead() {
 	<-time.After(50 * time.Millisecond)
 	for {
 		select {
-		case <-ledger.readChannel:
+		case _, ok := <-ledger.readChannel:
+			// The read thread stopped, the device is gone
+			if !ok {
+				return
+			}
 		default:
 			return
 		}
@@ -216,16 +341,19 @@ func (ledger *LedgerDeviceHID) drainRead() {
 }
 
 func (ledger *LedgerDeviceHID) Exchange(command []byte) ([]byte, error) {
+	ledger.exchangeMu.Lock()
+	defer ledger.exchangeMu.Unlock()
+
+	if ledger.closed.Load() {
+		return nil, ErrDeviceClosed
+	}
+
 	log.Printf("Sending command: %X", command)
 	// Purge messages that arrived after previous exchange completed
 	ledger.drainRead()
 
-	if len(command) < 5 {
-		return nil, fmt.Errorf("APDU commands should not be smaller than 5")
-	}
-
-	if (byte)(len(command)-5) != command[4] {
-		return nil, fmt.Errorf("APDU[data length] mismatch")
+	if err := ValidateCommand(command); err != nil {
+		return nil, err
 	}
 
 	serializedCommand, err := WrapCommandAPDU(Channel, command, PacketSize)
@@ -246,21 +374,24 @@ func (ledger *LedgerDeviceHID) Exchange(command []byte) ([]byte, error) {
 		return nil, err
 	}
 
-	if len(response) < 2 {
-		return nil, fmt.Errorf("len(response) < 2")
-	}
-
-	swOffset := len(response) - 2
-	sw := codec.Uint16(response[swOffset:])
-
-	if sw != 0x9000 {
-		return response[:swOffset], errors.New(ErrorMessage(sw))
+	data, err := ParseResponse(response)
+	if err != nil {
+		return data, err
 	}
 
 	log.Printf("Received response: %X", response)
-	return response[:swOffset], nil
+	return data, nil
 }
 
+// Close releases the device. A pending exchange fails as the read thread stops.
 func (ledger *LedgerDeviceHID) Close() error {
-	return ledger.device.Close()
+	if !ledger.closed.CompareAndSwap(false, true) {
+		return nil
+	}
+
+	err := ledger.device.Close()
+	if lockErr := ledger.lock.release(); err == nil {
+		err = lockErr
+	}
+	return err
 }
@@ -0,0 +1,61 @@
+/*******************************************************************************
+*   (c) Zondax AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+********************************************************************************/
+
+package ledger_go
+
+import (
+	"errors"
+
+	"github.com/zondax/hid"
+)
+
+// hidBackend abstracts device enumeration so the HID transport can run against fakes.
+type hidBackend interface {
+	Enumerate(vendorID uint16, productID uint16) []hid.DeviceInfo
+	Open(info hid.DeviceInfo) (hidDevice, error)
+}
+
+// hidDevice is the subset of *hid.Device used by LedgerDeviceHID.
+type hidDevice interface {
+	Read(buffer []byte) (int, error)
+	Write(buffer []byte) (int, error)
+	Close() error
+}
+
+// systemHID is the hidBackend backed by the OS through github.com/zondax/hid.
+type systemHID struct{}
+
+func (systemHID) Enumerate(vendorID uint16, productID uint16) []hid.DeviceInfo {
+	return hid.Enumerate(vendorID, productID)
+}
+
+func (systemHID) Open(info hid.DeviceInfo) (hidDevice, error) {
+	return info.Open()
+}
+
+// faultyHIDDevice reports injected disconnections the way the HID library reports
+// a closed device, so the read thread stops.
+type faultyHIDDevice struct {
+	*FaultyStream
+}
+
+func (d faultyHIDDevice) Read(buffer []byte) (int, error) {
+	n, err := d.FaultyStream.Read(buffer)
+	if errors.Is(err, ErrDisconnected) {
+		return n, hid.ErrDeviceClosed
+	}
+	return n, err
+}
@@ -0,0 +1,206 @@
+//go:build !ledger_mock && !ledger_zemu
+// +build !ledger_mock,!ledger_zemu
+
+/*******************************************************************************
+*   (c) Zondax AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+********************************************************************************/
+
+package ledger_go
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/zondax/hid"
+)
+
+// fakeHandler computes the raw reply (data + status word) for a command received by a fake device.
+type fakeHandler func(device *fakeHIDDevice, command []byte) []byte
+
+// fakeHID is a hidBackend simulating a set of connected devices.
+type fakeHID struct {
+	mu      sync.Mutex
+	devices []hid.DeviceInfo
+	handler fakeHandler
+	openErr error
+	opened  map[string]*fakeHIDDevice
+}
+
+func newFakeHID(handler fakeHandler, devices ...hid.DeviceInfo) *fakeHID {
+	return &fakeHID{
+		devices: devices,
+		handler: handler,
+		opened:  make(map[string]*fakeHIDDevice),
+	}
+}
+
+func (f *fakeHID) Enumerate(vendorID uint16, productID uint16) []hid.DeviceInfo {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var result []hid.DeviceInfo
+	for _, d := range f.devices {
+		if (vendorID == 0 || d.VendorID == vendorID) && (productID == 0 || d.ProductID == productID) {
+			result = append(result, d)
+		}
+	}
+	return result
+}
+
+func (f *fakeHID) Open(info hid.DeviceInfo) (hidDevice, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.openErr != nil {
+		return nil, f.openErr
+	}
+
+	device := newFakeHIDDevice(f.handler)
+	f.opened[info.Path] = device
+	return device, nil
+}
+
+func (f *fakeHID) device(path string) *fakeHIDDevice {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.opened[path]
+}
+
+type fakeRead struct {
+	data []byte
+	err  error
+}
+
+// fakeHIDDevice reassembles written packets into commands and answers them with framed replies.
+type fakeHIDDevice struct {
+	mu       sync.Mutex
+	handler  fakeHandler
+	maxWrite int
+	writeErr error
+
+	pending     []byte
+	command     []byte
+	commandSize int
+	sequenceIdx uint16
+	commands    [][]byte
+
+	reads     chan fakeRead
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+func newFakeHIDDevice(handler fakeHandler) *fakeHIDDevice {
+	return &fakeHIDDevice{
+		handler: handler,
+		reads:   make(chan fakeRead, 256),
+		closed:  make(chan struct{}),
+	}
+}
+
+func (d *fakeHIDDevice) Write(buffer []byte) (int, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.writeErr != nil {
+		return 0, d.writeErr
+	}
+
+	n := len(buffer)
+	if d.maxWrite > 0 && n > d.maxWrite {
+		n = d.maxWrite
+	}
+
+	d.pending = append(d.pending, buffer[:n]...)
+	for len(d.pending) >= PacketSize {
+		packet := d.pending[:PacketSize]
+		d.pending = d.pending[PacketSize:]
+		if err := d.receive(packet); err != nil {
+			return 0, err
+		}
+	}
+
+	return n, nil
+}
+
+func (d *fakeHIDDevice) receive(packet []byte) error {
+	data, size, _, err := DeserializePacket(Channel, packet, d.sequenceIdx)
+	if err != nil {
+		return err
+	}
+
+	if d.sequenceIdx == 0 {
+		d.commandSize = int(size)
+		d.command = nil
+	}
+	d.command = append(d.command, data...)
+	d.sequenceIdx++
+
+	if len(d.command) < d.commandSize {
+		return nil
+	}
+
+	command := d.command[:d.commandSize]
+	d.commands = append(d.commands, command)
+	d.sequenceIdx = 0
+
+	if d.handler == nil {
+		return nil
+	}
+	return d.reply(d.handler(d, command))
+}
+
+// reply queues a framed response for the reader.
+func (d *fakeHIDDevice) reply(response []byte) error {
+	packets, err := WrapCommandAPDU(Channel, response, PacketSize)
+	if err != nil {
+		return err
+	}
+
+	for len(packets) > 0 {
+		d.inject(packets[:PacketSize], nil)
+		packets = packets[PacketSize:]
+	}
+	return nil
+}
+
+// inject queues a raw read result, which may be an error.
+func (d *fakeHIDDevice) inject(data []byte, err error) {
+	d.reads <- fakeRead{data: data, err: err}
+}
+
+func (d *fakeHIDDevice) Read(buffer []byte) (int, error) {
+	select {
+	case r := <-d.reads:
+		if r.err != nil {
+			return 0, r.err
+		}
+		return copy(buffer, r.data), nil
+	case <-d.closed:
+		return 0, hid.ErrDeviceClosed
+	}
+}
+
+func (d *fakeHIDDevice) Close() error {
+	d.closeOnce.Do(func() { close(d.closed) })
+	return nil
+}
+
+func (d *fakeHIDDevice) receivedCommands() [][]byte {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.commands
+}
+
+var errFakeRead = errors.New("fake read error")
@@ -0,0 +1,278 @@
+//go:build !ledger_mock && !ledger_zemu
+// +build !ledger_mock,!ledger_zemu
+
+/*******************************************************************************
+*   (c) Zondax AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+********************************************************************************/
+
+package ledger_go
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zondax/hid"
+)
+
+var (
+	fakeNanoX     = hid.DeviceInfo{Path: "nanox", VendorID: VendorLedger, ProductID: 0x4011, Interface: 0}
+	fakeNanoSIf1  = hid.DeviceInfo{Path: "nanos-if1", VendorID: VendorLedger, ProductID: 0x1011, Interface: 1}
+	fakeUsagePage = hid.DeviceInfo{Path: "usagepage", VendorID: VendorLedger, ProductID: 0x0001, UsagePage: UsagePageLedgerNanoS}
+	fakeMouse     = hid.DeviceInfo{Path: "mouse", VendorID: 0x046d, ProductID: 0xc52b}
+)
+
+// echoHandler replies with the command itself followed by 0x9000
+func echoHandler(_ *fakeHIDDevice, command []byte) []byte {
+	return append(append([]byte{}, command...), 0x90, 0x00)
+}
+
+func newFakeAdmin(handler fakeHandler) (*LedgerAdminHID, *fakeHID) {
+	backend := newFakeHID(handler, fakeMouse, fakeNanoX, fakeNanoSIf1, fakeUsagePage)
+	return &LedgerAdminHID{backend: backend}, backend
+}
+
+func testCommand(dataLength int) []byte {
+	command := []byte{0xE0, 0x02, 0x00, 0x00, byte(dataLength)}
+	for i := 0; i < dataLength; i++ {
+		command = append(command, byte(i))
+	}
+	return command
+}
+
+func TestHIDCountDevices(t *testing.T) {
+	admin, _ := newFakeAdmin(echoHandler)
+	assert.Equal(t, 2, admin.CountDevices())
+}
+
+func TestHIDDescriptors(t *testing.T) {
+	admin, _ := newFakeAdmin(echoHandler)
+
+	descriptors := admin.Descriptors()
+	require.Len(t, descriptors, 2)
+	assert.Equal(t, DeviceDescriptor{Index: 0, Path: "nanox", Model: "Nano X", VendorID: VendorLedger, ProductID: 0x4011}, descriptors[0])
+	assert.Equal(t, "usagepage", descriptors[1].Path)
+	assert.Equal(t, "Unknown", descriptors[1].Model)
+	assert.Equal(t, 1, descriptors[1].Index)
+}
+
+func TestHIDConnectIndex(t *testing.T) {
+	admin, backend := newFakeAdmin(echoHandler)
+
+	device, err := admin.Connect(1)
+	require.NoError(t, err)
+	defer device.Close()
+	assert.NotNil(t, backend.device(fakeUsagePage.Path))
+
+	_, err = admin.Connect(2)
+	assert.ErrorIs(t, err, ErrDeviceNotFound)
+}
+
+func TestHIDConnectOpenError(t *testing.T) {
+	admin, backend := newFakeAdmin(echoHandler)
+	backend.openErr = errors.New("open failed")
+
+	_, err := admin.Connect(0)
+	assert.EqualError(t, err, "open failed")
+}
+
+func TestHIDExchange(t *testing.T) {
+	admin, backend := newFakeAdmin(echoHandler)
+
+	device, err := admin.Connect(0)
+	require.NoError(t, err)
+	defer device.Close()
+
+	for _, size := range []int{0, 10, 200} {
+		command := testCommand(size)
+		response, err := device.Exchange(command)
+		require.NoError(t, err)
+		assert.Equal(t, command, response)
+	}
+
+	assert.Len(t, backend.device(fakeNanoX.Path).receivedCommands(), 3)
+}
+
+func TestHIDExchangePartialWrites(t *testing.T) {
+	admin, backend := newFakeAdmin(echoHandler)
+
+	device, err := admin.Connect(0)
+	require.NoError(t, err)
+	defer device.Close()
+	backend.device(fakeNanoX.Path).maxWrite = 10
+
+	command := testCommand(150)
+	response, err := device.Exchange(command)
+	require.NoError(t, err)
+	assert.Equal(t, command, response)
+}
+
+func TestHIDExchangeSkipsZeroPacketsAndReadErrors(t *testing.T) {
+	admin, _ := newFakeAdmin(func(device *fakeHIDDevice, command []byte) []byte {
+		device.inject(make([]byte, PacketSize), nil)
+		device.inject(nil, errFakeRead)
+		device.inject(make([]byte, PacketSize), nil)
+		return echoHandler(device, command)
+	})
+
+	device, err := admin.Connect(0)
+	require.NoError(t, err)
+	defer device.Close()
+
+	command := testCommand(100)
+	response, err := device.Exchange(command)
+	require.NoError(t, err)
+	assert.Equal(t, command, response)
+}
+
+func TestHIDExchangeStatusWord(t *testing.T) {
+	admin, _ := newFakeAdmin(func(_ *fakeHIDDevice, _ []byte) []byte {
+		return []byte{0x01, 0x02, 0x69, 0x86}
+	})
+
+	device, err := admin.Connect(0)
+	require.NoError(t, err)
+	defer device.Close()
+
+	response, err := device.Exchange(testCommand(0))
+	assert.EqualError(t, err, ErrorMessage(0x6986))
+	assert.Equal(t, []byte{0x01, 0x02}, response)
+}
+
+func TestHIDExchangeInvalidCommand(t *testing.T) {
+	admin, backend := newFakeAdmin(echoHandler)
+
+	device, err := admin.Connect(0)
+	require.NoError(t, err)
+	defer device.Close()
+
+	_, err = device.Exchange([]byte{0xE0, 0x01})
+	assert.Error(t, err)
+
+	_, err = device.Exchange([]byte{0xE0, 0x01, 0x00, 0x00, 0x02, 0x00})
+	assert.Error(t, err)
+
+	assert.Empty(t, backend.device(fakeNanoX.Path).receivedCommands())
+}
+
+func TestHIDExchangeWriteError(t *testing.T) {
+	admin, backend := newFakeAdmin(echoHandler)
+
+	device, err := admin.Connect(0)
+	require.NoError(t, err)
+	defer device.Close()
+	backend.device(fakeNanoX.Path).writeErr = errors.New("write failed")
+
+	_, err = device.Exchange(testCommand(0))
+	assert.EqualError(t, err, "write failed")
+}
+
+func TestHIDCloseStopsReadThread(t *testing.T) {
+	admin, _ := newFakeAdmin(echoHandler)
+
+	device, err := admin.Connect(0)
+	require.NoError(t, err)
+
+	readChannel := device.(*LedgerDeviceHID).Read()
+	require.NoError(t, device.Close())
+
+	select {
+	case _, ok := <-readChannel:
+		assert.False(t, ok, "read channel should be closed")
+	case <-time.After(time.Second):
+		t.Fatal("read thread did not stop after Close")
+	}
+}
+
+func TestHIDConnectExclusive(t *testing.T) {
+	backend := newFakeHID(echoHandler, fakeNanoX)
+	admin := &LedgerAdminHID{backend: backend, exclusive: true, lockDir: t.TempDir()}
+
+	device, err := admin.Connect(0)
+	require.NoError(t, err)
+
+	_, err = admin.Connect(0)
+	assert.ErrorIs(t, err, ErrDeviceBusy)
+
+	require.NoError(t, device.Close())
+
+	device, err = admin.Connect(0)
+	require.NoError(t, err)
+	assert.NoError(t, device.Close())
+}
+
+func TestHIDVirtualDevice(t *testing.T) {
+	virtual := newVirtualApp()
+	backend := newFakeHID(func(_ *fakeHIDDevice, command []byte) []byte {
+		return virtual.Process(command)
+	}, fakeNanoX)
+	admin := &LedgerAdminHID{backend: backend}
+
+	device, err := admin.Connect(0)
+	require.NoError(t, err)
+	defer device.Close()
+
+	client := NewAppClient(device, AppConfig{CLA: virtualCLA})
+	version, err := client.GetVersion()
+	require.NoError(t, err)
+	assert.Equal(t, "1.2.3", version.String())
+
+	signature, err := client.Sign(testPath, []byte("message"))
+	require.NoError(t, err)
+	assert.Len(t, signature, 32)
+}
+
+func TestHIDFaultInjection(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   FaultConfig
+		expected error
+	}{
+		{"WrongChannel", FaultConfig{WrongChannelRate: 1}, ErrInvalidChannel},
+		{"Disconnected", FaultConfig{DisconnectAfter: 1}, ErrIncompleteResponse},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backend := newFakeHID(echoHandler, fakeNanoX)
+			config := tt.config
+			admin := &LedgerAdminHID{backend: backend, faults: &config}
+
+			device, err := admin.Connect(0)
+			require.NoError(t, err)
+			defer device.Close()
+
+			_, err = device.Exchange(testCommand(10))
+			assert.ErrorIs(t, err, tt.expected)
+		})
+	}
+}
+
+func TestHIDFaultDisconnectStopsExchanges(t *testing.T) {
+	backend := newFakeHID(echoHandler, fakeNanoX)
+	admin := &LedgerAdminHID{backend: backend, faults: &FaultConfig{DisconnectAfter: 1}}
+
+	device, err := admin.Connect(0)
+	require.NoError(t, err)
+	defer device.Close()
+
+	_, err = device.Exchange(testCommand(10))
+	assert.Error(t, err)
+
+	_, err = device.Exchange(testCommand(10))
+	assert.ErrorIs(t, err, ErrDisconnected)
+}
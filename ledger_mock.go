@@ -22,18 +22,28 @@ package ledger_go
 import (
 	"encoding/hex"
 	"fmt"
+	"sync"
 )
 
 const mockDeviceName = "Mock device"
 
-type LedgerAdminMock struct{}
+// LedgerAdminMock is the admin returned by NewLedgerAdmin in ledger_mock builds.
+// It exposes a single LedgerDeviceMock, kept across connections so that its replies
+// can be scripted. The ledgertest package provides mocks for normal builds.
+type LedgerAdminMock struct {
+	device *LedgerDeviceMock
+}
 
+// LedgerDeviceMock answers commands with the raw replies set with SetCommandReplies.
 type LedgerDeviceMock struct {
+	mu       sync.Mutex
 	commands map[string]string
+	closed   bool
 }
 
+// NewLedgerAdmin returns a mock admin with a single LedgerDeviceMock.
 func NewLedgerAdmin() LedgerAdmin {
-	return &LedgerAdminMock{}
+	return &LedgerAdminMock{device: NewLedgerDeviceMock()}
 }
 
 func (admin *LedgerAdminMock) ListDevices() ([]string, error) {
@@ -44,8 +54,24 @@ func (admin *LedgerAdminMock) CountDevices() int {
 	return 1
 }
 
+// Device returns the device at deviceIndex without connecting, e.g. to script it.
+func (admin *LedgerAdminMock) Device(deviceIndex int) (LedgerDevice, error) {
+	if deviceIndex != 0 {
+		return nil, fmt.Errorf("mock %w (idx %d): 1 device available", ErrDeviceNotFound, deviceIndex)
+	}
+	return admin.device, nil
+}
+
+// Connect returns the mock device, usable again if it was closed.
 func (admin *LedgerAdminMock) Connect(deviceIndex int) (LedgerDevice, error) {
-	return NewLedgerDeviceMock(), nil
+	if _, err := admin.Device(deviceIndex); err != nil {
+		return nil, err
+	}
+
+	admin.device.mu.Lock()
+	admin.device.closed = false
+	admin.device.mu.Unlock()
+	return admin.device, nil
 }
 
 func NewLedgerDeviceMock() *LedgerDeviceMock {
@@ -54,23 +80,51 @@ func NewLedgerDeviceMock() *LedgerDeviceMock {
 	}
 }
 
+// Exchange validates the command and processes the scripted reply, including its
+// trailing status word, the same way the HID and Zemu transports do.
 func (ledger *LedgerDeviceMock) Exchange(command []byte) ([]byte, error) {
+	if err := ValidateCommand(command); err != nil {
+		return nil, err
+	}
+
+	ledger.mu.Lock()
+	closed := ledger.closed
 	hexCommand := hex.EncodeToString(command)
-	if reply, ok := ledger.commands[hexCommand]; ok {
-		return hex.DecodeString(reply)
+	reply, ok := ledger.commands[hexCommand]
+	ledger.mu.Unlock()
+
+	if closed {
+		return nil, ErrDeviceClosed
+	}
+	if !ok {
+		return nil, fmt.Errorf("unknown command: %s", hexCommand)
 	}
-	return nil, fmt.Errorf("unknown command: %s", hexCommand)
+
+	response, err := hex.DecodeString(reply)
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseResponse(response)
 }
 
+// SetCommandReplies sets the raw replies, as hex data followed by the status word, for each hex command.
 func (ledger *LedgerDeviceMock) SetCommandReplies(commands map[string]string) {
+	ledger.mu.Lock()
+	defer ledger.mu.Unlock()
 	ledger.commands = commands
 }
 
 func (ledger *LedgerDeviceMock) ClearCommands() {
+	ledger.mu.Lock()
+	defer ledger.mu.Unlock()
 	ledger.commands = make(map[string]string)
 }
 
+// Close makes further exchanges fail with ErrDeviceClosed until the device is connected again.
 func (ledger *LedgerDeviceMock) Close() error {
-	// Nothing to do here
+	ledger.mu.Lock()
+	defer ledger.mu.Unlock()
+	ledger.closed = true
 	return nil
 }
@@ -41,6 +41,7 @@ func TestLedger(t *testing.T) {
 		{"BasicExchange", Test_BasicExchange},
 		{"Connect", TestConnect},
 		{"GetVersion", TestGetVersion},
+		{"StatusWords", TestStatusWords},
 	}
 
 	for _, tt := range tests {
@@ -102,7 +103,7 @@ func Test_BasicExchange(t *testing.T) {
 	// Set expected replies for the commands (only if using mock)
 	if mockLedger, ok := ledger.(*LedgerDeviceMock); ok {
 		mockLedger.SetCommandReplies(map[string]string{
-			"e001000000": "311000040853706563756c6f73000b53706563756c6f734d4355",
+			"e001000000": "311000040853706563756c6f73000b53706563756c6f734d43559000",
 		})
 	}
 
@@ -145,14 +146,54 @@ func TestGetVersion(t *testing.T) {
 	// Set expected replies for the commands (only if using mock)
 	if mockLedger, ok := ledger.(*LedgerDeviceMock); ok {
 		mockLedger.SetCommandReplies(map[string]string{
-			"e001000000": "311000040853706563756c6f73000b53706563756c6f734d4355",
+			"e001000000": "311000040853706563756c6f73000b53706563756c6f734d43559000",
 		})
 	}
 
 	// Call device info (this should work in main menu and many apps)
-	message := []byte{0xE0, 0x01, 0, 0, 0}
+	info, err := GetDeviceInfo(ledger)
+	require.NoError(t, err)
+	assert.NotEmpty(t, info.SEVersion, "SE version should not be empty")
+	assert.NotEmpty(t, info.MCUVersion, "MCU version should not be empty")
+}
 
-	response, err := ledger.Exchange(message)
-	assert.NoError(t, err)
-	assert.NotEmpty(t, response, "Response should not be empty")
+func TestStatusWords(t *testing.T) {
+	ledgerAdmin := NewLedgerAdmin()
+
+	ledger, err := ledgerAdmin.Connect(0)
+	if err != nil {
+		t.Fatalf("Error connecting to ledger: %v", err)
+	}
+	defer ledger.Close()
+
+	mockLedger, ok := ledger.(*LedgerDeviceMock)
+	if !ok {
+		t.Skip("status words can only be scripted with the mock")
+	}
+
+	mockLedger.SetCommandReplies(map[string]string{
+		"e001000000": "01026986",
+		"e002000000": "01",
+	})
+
+	response, err := ledger.Exchange([]byte{0xE0, 0x01, 0, 0, 0})
+	assert.EqualError(t, err, ErrorMessage(0x6986))
+	assert.Equal(t, []byte{0x01, 0x02}, response)
+
+	_, err = ledger.Exchange([]byte{0xE0, 0x02, 0, 0, 0})
+	assert.ErrorIs(t, err, ErrResponseTooShort)
+
+	_, err = ledger.Exchange([]byte{0xE0, 0x01, 0, 0})
+	assert.ErrorIs(t, err, ErrCommandTooShort)
+}
+
+func TestMockAdminDefault(t *testing.T) {
+	admin := NewLedgerAdmin().(*LedgerAdminMock)
+
+	device, err := admin.Device(0)
+	require.NoError(t, err)
+	assert.IsType(t, &LedgerDeviceMock{}, device)
+
+	_, err = admin.Device(1)
+	assert.ErrorIs(t, err, ErrDeviceNotFound)
 }
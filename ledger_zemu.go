@@ -1,6 +1,3 @@
-//go:build ledger_zemu
-// +build ledger_zemu
-
 /*******************************************************************************
 *   (c) Zondax AG
 *
@@ -21,95 +18,373 @@ package ledger_go
 
 import (
 	"context"
-	"errors"
+	"crypto/tls"
 	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"sync/atomic"
+	"time"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/status"
 )
 
 const (
 	defaultGrpcURL  = "localhost"
 	defaultGrpcPort = "3002"
+
+	// ZemuAddressEnv overrides the default emulator address, as "host:port"
+	ZemuAddressEnv = "LEDGER_ZEMU_ADDRESS"
+
+	defaultZemuProbeTimeout   = time.Second
+	defaultZemuConnectTimeout = 5 * time.Second
+	zemuDeviceName            = "Zemu device"
 )
 
 type LedgerAdminZemu struct {
-	grpcURL  string
-	grpcPort string
+	grpcURL        string
+	grpcPort       string
+	probeTimeout   time.Duration
+	connectTimeout time.Duration
+	tlsConfig      *tls.Config
+	keepalive      *keepalive.ClientParameters
+	dialOptions    []grpc.DialOption
 }
 
 type LedgerDeviceZemu struct {
 	connection *grpc.ClientConn
 	client     ZemuCommandClient
+	closed     atomic.Bool
+}
+
+// ZemuOption configures a LedgerAdminZemu.
+type ZemuOption func(admin *LedgerAdminZemu)
+
+// WithZemuAddress sets the emulator address, as "host:port". A bare host keeps the current port.
+func WithZemuAddress(address string) ZemuOption {
+	return func(admin *LedgerAdminZemu) {
+		host, port, err := net.SplitHostPort(address)
+		if err != nil {
+			admin.grpcURL = address
+			return
+		}
+		admin.grpcURL, admin.grpcPort = host, port
+	}
+}
+
+// WithZemuHost sets the emulator host.
+func WithZemuHost(host string) ZemuOption {
+	return func(admin *LedgerAdminZemu) {
+		admin.grpcURL = host
+	}
+}
+
+// WithZemuPort sets the emulator port.
+func WithZemuPort(port int) ZemuOption {
+	return func(admin *LedgerAdminZemu) {
+		admin.grpcPort = strconv.Itoa(port)
+	}
 }
 
-func NewLedgerAdmin() *LedgerAdminZemu {
-	return &LedgerAdminZemu{
-		grpcURL:  defaultGrpcURL,
-		grpcPort: defaultGrpcPort,
+// WithZemuProbeTimeout bounds the reachability check of ListDevices and CountDevices.
+func WithZemuProbeTimeout(timeout time.Duration) ZemuOption {
+	return func(admin *LedgerAdminZemu) {
+		admin.probeTimeout = timeout
 	}
 }
 
+// WithZemuConnectTimeout bounds the connection and health check done by Connect.
+func WithZemuConnectTimeout(timeout time.Duration) ZemuOption {
+	return func(admin *LedgerAdminZemu) {
+		admin.connectTimeout = timeout
+	}
+}
+
+// WithZemuTLS connects to the emulator over TLS instead of plaintext.
+func WithZemuTLS(config *tls.Config) ZemuOption {
+	return func(admin *LedgerAdminZemu) {
+		admin.tlsConfig = config
+	}
+}
+
+// WithZemuKeepalive enables keepalive pings on the connection.
+func WithZemuKeepalive(params keepalive.ClientParameters) ZemuOption {
+	return func(admin *LedgerAdminZemu) {
+		admin.keepalive = &params
+	}
+}
+
+// WithZemuDialOptions adds grpc dial options, applied after the ones set by other options.
+func WithZemuDialOptions(options ...grpc.DialOption) ZemuOption {
+	return func(admin *LedgerAdminZemu) {
+		admin.dialOptions = append(admin.dialOptions, options...)
+	}
+}
+
+// NewLedgerAdminZemu returns an admin for a single emulator. Options take precedence over
+// the LEDGER_ZEMU_ADDRESS environment variable, which takes precedence over localhost:3002.
+// Admins are independent, so several emulators can be used in the same process.
+func NewLedgerAdminZemu(options ...ZemuOption) *LedgerAdminZemu {
+	admin := &LedgerAdminZemu{
+		grpcURL:        defaultGrpcURL,
+		grpcPort:       defaultGrpcPort,
+		probeTimeout:   defaultZemuProbeTimeout,
+		connectTimeout: defaultZemuConnectTimeout,
+	}
+
+	if address := os.Getenv(ZemuAddressEnv); address != "" {
+		WithZemuAddress(address)(admin)
+	}
+
+	for _, option := range options {
+		option(admin)
+	}
+
+	return admin
+}
+
+// Address returns the emulator address, as "host:port".
+func (admin *LedgerAdminZemu) Address() string {
+	return net.JoinHostPort(admin.grpcURL, admin.grpcPort)
+}
+
+// reachable reports whether the emulator accepts connections.
+func (admin *LedgerAdminZemu) reachable() bool {
+	conn, err := net.DialTimeout("tcp", admin.Address(), admin.probeTimeout)
+	if err != nil {
+		return false
+	}
+	_ = conn.Close()
+	return true
+}
+
+// ListDevices returns the emulator when it is reachable.
 func (admin *LedgerAdminZemu) ListDevices() ([]string, error) {
-	// It does not make sense for zemu devices
-	x := []string{"Zemu device"}
-	return x, nil
+	if !admin.reachable() {
+		return []string{}, nil
+	}
+	return []string{fmt.Sprintf("%s (%s)", zemuDeviceName, admin.Address())}, nil
 }
 
+// CountDevices returns 1 when the emulator is reachable, 0 otherwise.
 func (admin *LedgerAdminZemu) CountDevices() int {
-	// TODO: Always 1, maybe zero if zemu has not elf??
+	if !admin.reachable() {
+		return 0
+	}
 	return 1
 }
 
-func (admin *LedgerAdminZemu) Connect(deviceIndex int) (*LedgerDeviceZemu, error) {
-	serverAddr := admin.grpcURL + ":" + admin.grpcPort
-	//TODO: check Dial flags
-	conn, err := grpc.Dial(serverAddr, grpc.WithInsecure())
+func (admin *LedgerAdminZemu) clientOptions() []grpc.DialOption {
+	creds := insecure.NewCredentials()
+	if admin.tlsConfig != nil {
+		creds = credentials.NewTLS(admin.tlsConfig)
+	}
+
+	options := []grpc.DialOption{grpc.WithTransportCredentials(creds)}
+	if admin.keepalive != nil {
+		options = append(options, grpc.WithKeepaliveParams(*admin.keepalive))
+	}
+	return append(options, admin.dialOptions...)
+}
+
+// Connect opens a connection to the emulator and checks that it is serving.
+// Failures to reach it are reported as ErrEmulatorUnreachable.
+func (admin *LedgerAdminZemu) Connect(deviceIndex int) (LedgerDevice, error) {
+	if deviceIndex != 0 {
+		return nil, fmt.Errorf("zemu %w (idx %d): only one emulated device", ErrDeviceNotFound, deviceIndex)
+	}
 
+	serverAddr := admin.Address()
+	conn, err := grpc.NewClient(serverAddr, admin.clientOptions()...)
 	if err != nil {
-		err = fmt.Errorf("could not connect to rpc server at %q : %q", serverAddr, err)
-		return &LedgerDeviceZemu{}, err
+		return nil, fmt.Errorf("could not create rpc client for %q: %w", serverAddr, err)
 	}
 
-	client := NewZemuCommandClient(conn)
+	ctx, cancel := context.WithTimeout(context.Background(), admin.connectTimeout)
+	defer cancel()
+
+	if err := checkZemuHealth(ctx, conn); err != nil {
+		_ = conn.Close()
+		return nil, fmt.Errorf("%w at %q: %w", ErrEmulatorUnreachable, serverAddr, err)
+	}
 
-	return &LedgerDeviceZemu{connection: conn, client: client}, nil
+	return &LedgerDeviceZemu{connection: conn, client: NewZemuCommandClient(conn)}, nil
 }
 
-func (ledger *LedgerDeviceZemu) Exchange(command []byte) ([]byte, error) {
+// checkZemuHealth waits for the connection to be ready, then queries the grpc health
+// service. Emulators that do not implement it are considered healthy once connected.
+// Failed attempts are retried until ctx expires, so an emulator still starting up is waited for.
+func checkZemuHealth(ctx context.Context, conn *grpc.ClientConn) error {
+	for {
+		state := conn.GetState()
+		if state == connectivity.Ready {
+			break
+		}
+		if state == connectivity.Shutdown {
+			return fmt.Errorf("connection state %s", state)
+		}
+		if state == connectivity.Idle {
+			conn.Connect()
+		}
+		if !conn.WaitForStateChange(ctx, state) {
+			return fmt.Errorf("connection state %s: %w", conn.GetState(), ctx.Err())
+		}
+	}
 
-	if len(command) < 5 {
-		return nil, fmt.Errorf("APDU commands should not be smaller than 5")
+	response, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{})
+	switch {
+	case status.Code(err) == codes.Unimplemented:
+		return nil
+	case err != nil:
+		return err
+	case response.GetStatus() != healthpb.HealthCheckResponse_SERVING:
+		return fmt.Errorf("health status %s", response.GetStatus())
 	}
+	return nil
+}
 
-	if (byte)(len(command)-5) != command[4] {
-		return nil, fmt.Errorf("APDU[data length] mismatch")
+func (ledger *LedgerDeviceZemu) Exchange(command []byte) ([]byte, error) {
+	if ledger.closed.Load() {
+		return nil, ErrDeviceClosed
+	}
+
+	if err := ValidateCommand(command); err != nil {
+		return nil, err
 	}
 
 	// Send to Zemu and return reply or error
 	r, err := ledger.client.Exchange(context.Background(), &ExchangeRequest{Command: command})
+	if err != nil {
+		return nil, zemuCallError(err)
+	}
+
+	return ParseResponse(r.Reply)
+}
+
+// control checks that the device is open before an emulator control call.
+func (ledger *LedgerDeviceZemu) control() error {
+	if ledger.closed.Load() {
+		return ErrDeviceClosed
+	}
+	return nil
+}
+
+// PressButton presses a button on the emulator and returns the text on screen afterwards.
+func (ledger *LedgerDeviceZemu) PressButton(button ZemuButton) ([]string, error) {
+	if err := ledger.control(); err != nil {
+		return nil, err
+	}
+
+	r, err := ledger.client.PressButton(context.Background(), &ButtonRequest{Button: button})
+	if err != nil {
+		return nil, zemuCallError(err)
+	}
+	return r.Screen, nil
+}
+
+func (ledger *LedgerDeviceZemu) PressLeft() ([]string, error) {
+	return ledger.PressButton(ZemuButton_BUTTON_LEFT)
+}
+
+func (ledger *LedgerDeviceZemu) PressRight() ([]string, error) {
+	return ledger.PressButton(ZemuButton_BUTTON_RIGHT)
+}
+
+func (ledger *LedgerDeviceZemu) PressBoth() ([]string, error) {
+	return ledger.PressButton(ZemuButton_BUTTON_BOTH)
+}
+
+// ClickThrough goes through the review of the pending request and approves or rejects it.
+// The review may not span more than maxScreens screens, the emulator default applies when zero.
+func (ledger *LedgerDeviceZemu) ClickThrough(reject bool, maxScreens int) ([]string, error) {
+	if err := ledger.control(); err != nil {
+		return nil, err
+	}
 
+	r, err := ledger.client.ClickThrough(context.Background(), &ClickThroughRequest{Reject: reject, MaxScreens: uint32(maxScreens)})
 	if err != nil {
-		err = fmt.Errorf("could not call rpc service: %q", err)
-		return []byte{}, err
+		return nil, zemuCallError(err)
 	}
+	return r.Screen, nil
+}
 
-	response := r.Reply
+// Approve clicks through the review of the pending request and approves it.
+func (ledger *LedgerDeviceZemu) Approve() error {
+	_, err := ledger.ClickThrough(false, 0)
+	return err
+}
+
+// Reject clicks through the review of the pending request and rejects it.
+func (ledger *LedgerDeviceZemu) Reject() error {
+	_, err := ledger.ClickThrough(true, 0)
+	return err
+}
 
-	if len(response) < 2 {
-		return nil, fmt.Errorf("len(response) < 2")
+// Snapshot returns a PNG image of the emulator screen and the text on it.
+func (ledger *LedgerDeviceZemu) Snapshot() ([]byte, []string, error) {
+	if err := ledger.control(); err != nil {
+		return nil, nil, err
 	}
 
-	swOffset := len(response) - 2
-	sw := codec.Uint16(response[swOffset:])
+	r, err := ledger.client.Snapshot(context.Background(), &SnapshotRequest{})
+	if err != nil {
+		return nil, nil, zemuCallError(err)
+	}
+	return r.Image, r.Screen, nil
+}
 
-	if sw != 0x9000 {
-		return response[:swOffset], errors.New(ErrorMessage(sw))
+// WaitForText waits until text shows on screen and returns the screen.
+// ErrTextNotFound is returned when it does not show within timeout.
+func (ledger *LedgerDeviceZemu) WaitForText(text string, timeout time.Duration) ([]string, error) {
+	if err := ledger.control(); err != nil {
+		return nil, err
 	}
 
-	return response[:swOffset], nil
+	request := &WaitForTextRequest{Text: text, TimeoutMs: uint32(timeout.Milliseconds())}
+	r, err := ledger.client.WaitForText(context.Background(), request)
+	if err != nil {
+		return nil, zemuCallError(err)
+	}
+	if !r.Found {
+		return r.Screen, fmt.Errorf("%w: %q not in %q", ErrTextNotFound, text, r.Screen)
+	}
+	return r.Screen, nil
+}
+
+// Reset rejects any pending request and brings the emulator back to its home screen.
+func (ledger *LedgerDeviceZemu) Reset() error {
+	if err := ledger.control(); err != nil {
+		return err
+	}
+
+	if _, err := ledger.client.Reset(context.Background(), &ResetRequest{}); err != nil {
+		return zemuCallError(err)
+	}
+	return nil
+}
+
+// zemuCallError tells transport failures apart from other rpc errors.
+func zemuCallError(err error) error {
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded:
+		return fmt.Errorf("%w: %w", ErrEmulatorUnreachable, err)
+	default:
+		return fmt.Errorf("could not call rpc service: %w", err)
+	}
 }
 
 func (ledger *LedgerDeviceZemu) Close() error {
+	if !ledger.closed.CompareAndSwap(false, true) {
+		return nil
+	}
+
 	err := ledger.connection.Close()
 
 	if err != nil {
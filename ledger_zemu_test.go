@@ -0,0 +1,316 @@
+/*******************************************************************************
+*   (c) Zondax AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+********************************************************************************/
+package ledger_go_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/keepalive"
+
+	ledger_go "github.com/zondax/ledger-go"
+	"github.com/zondax/ledger-go/ledgertest"
+)
+
+// startZemuServer serves the services set up by register on a free local port and returns its address
+func startZemuServer(t *testing.T, register func(server *grpc.Server), options ...grpc.ServerOption) (string, *grpc.Server) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	server := grpc.NewServer(options...)
+	register(server)
+	go func() { _ = server.Serve(listener) }()
+	t.Cleanup(server.Stop)
+
+	return listener.Addr().String(), server
+}
+
+// startZemuStandIn serves device on a free local port and returns its address
+func startZemuStandIn(t *testing.T, device *ledger_go.VirtualDevice, options ...grpc.ServerOption) string {
+	address, _ := startZemuServer(t, func(server *grpc.Server) {
+		ledger_go.RegisterZemuCommandServer(server, ledger_go.NewZemuEmulator(device))
+	}, options...)
+	return address
+}
+
+// unusedAddress returns a local address nothing listens on
+func unusedAddress(t *testing.T) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	address := listener.Addr().String()
+	require.NoError(t, listener.Close())
+	return address
+}
+
+func TestZemuAddress(t *testing.T) {
+	t.Setenv(ledger_go.ZemuAddressEnv, "")
+	assert.Equal(t, "localhost:3002", ledger_go.NewLedgerAdminZemu().Address())
+
+	t.Setenv(ledger_go.ZemuAddressEnv, "zemu.ci:4000")
+	assert.Equal(t, "zemu.ci:4000", ledger_go.NewLedgerAdminZemu().Address())
+	assert.Equal(t, "zemu.ci:4001", ledger_go.NewLedgerAdminZemu(ledger_go.WithZemuPort(4001)).Address())
+	assert.Equal(t, "other:4000", ledger_go.NewLedgerAdminZemu(ledger_go.WithZemuAddress("other")).Address())
+	assert.Equal(t, "[::1]:5000", ledger_go.NewLedgerAdminZemu(ledger_go.WithZemuAddress("[::1]:5000")).Address())
+	assert.Equal(t, "host:4000", ledger_go.NewLedgerAdminZemu(ledger_go.WithZemuHost("host")).Address())
+}
+
+func TestZemuProbe(t *testing.T) {
+	admin := ledger_go.NewLedgerAdminZemu(
+		ledger_go.WithZemuAddress(unusedAddress(t)),
+		ledger_go.WithZemuProbeTimeout(100*time.Millisecond),
+	)
+
+	assert.Equal(t, 0, admin.CountDevices())
+	devices, err := admin.ListDevices()
+	require.NoError(t, err)
+	assert.Empty(t, devices)
+
+	address := startZemuStandIn(t, ledgertest.NewReferenceApp())
+	admin = ledger_go.NewLedgerAdminZemu(ledger_go.WithZemuAddress(address))
+
+	assert.Equal(t, 1, admin.CountDevices())
+	devices, err = admin.ListDevices()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Zemu device (" + address + ")"}, devices)
+}
+
+func TestZemuMultipleAdmins(t *testing.T) {
+	var admins []*ledger_go.LedgerAdminZemu
+	for _, version := range []byte{1, 2} {
+		version := version
+		app := ledger_go.NewVirtualDevice()
+		app.Handle(0x55, ledger_go.InsGetVersion, func(_ *ledger_go.Command) ([]byte, uint16) {
+			return []byte{0x00, version, 0x00, 0x00}, ledger_go.SwOK
+		})
+		admins = append(admins, ledger_go.NewLedgerAdminZemu(ledger_go.WithZemuAddress(startZemuStandIn(t, app))))
+	}
+
+	for i, admin := range admins {
+		device, err := admin.Connect(0)
+		require.NoError(t, err)
+
+		response, err := device.Exchange([]byte{0x55, ledger_go.InsGetVersion, 0x00, 0x00, 0x00})
+		require.NoError(t, err)
+		assert.Equal(t, byte(i+1), response[1])
+		require.NoError(t, device.Close())
+	}
+}
+
+func TestZemuConnectUnreachable(t *testing.T) {
+	admin := ledger_go.NewLedgerAdminZemu(
+		ledger_go.WithZemuAddress(unusedAddress(t)),
+		ledger_go.WithZemuConnectTimeout(200*time.Millisecond),
+	)
+
+	_, err := admin.Connect(0)
+	assert.ErrorIs(t, err, ledger_go.ErrEmulatorUnreachable)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.ErrorContains(t, err, "connection state TRANSIENT_FAILURE")
+}
+
+func TestZemuConnectWaitsForStartup(t *testing.T) {
+	address := unusedAddress(t)
+	admin := ledger_go.NewLedgerAdminZemu(ledger_go.WithZemuAddress(address))
+
+	// The emulator starts listening after the first connection attempts were refused
+	started := make(chan struct{})
+	go func() {
+		defer close(started)
+		time.Sleep(200 * time.Millisecond)
+		listener, err := net.Listen("tcp", address)
+		if err != nil {
+			t.Errorf("listen: %v", err)
+			return
+		}
+		server := grpc.NewServer()
+		ledger_go.RegisterZemuCommandServer(server, ledger_go.NewZemuEmulator(ledgertest.NewReferenceApp()))
+		go func() { _ = server.Serve(listener) }()
+		t.Cleanup(server.Stop)
+	}()
+
+	device, err := admin.Connect(0)
+	<-started
+	require.NoError(t, err)
+	assert.NoError(t, device.Close())
+}
+
+func TestZemuConnectHealthCheck(t *testing.T) {
+	healthServer := health.NewServer()
+	address, _ := startZemuServer(t, func(server *grpc.Server) {
+		ledger_go.RegisterZemuCommandServer(server, ledger_go.NewZemuEmulator(ledgertest.NewReferenceApp()))
+		healthpb.RegisterHealthServer(server, healthServer)
+	})
+	admin := ledger_go.NewLedgerAdminZemu(ledger_go.WithZemuAddress(address))
+
+	healthServer.SetServingStatus("", healthpb.HealthCheckResponse_NOT_SERVING)
+	_, err := admin.Connect(0)
+	assert.ErrorIs(t, err, ledger_go.ErrEmulatorUnreachable)
+
+	healthServer.SetServingStatus("", healthpb.HealthCheckResponse_SERVING)
+	device, err := admin.Connect(0)
+	require.NoError(t, err)
+	assert.NoError(t, device.Close())
+}
+
+func TestZemuExchangeErrors(t *testing.T) {
+	address, server := startZemuServer(t, func(server *grpc.Server) {
+		ledger_go.RegisterZemuCommandServer(server, ledger_go.NewZemuEmulator(ledgertest.NewReferenceApp()))
+	})
+	admin := ledger_go.NewLedgerAdminZemu(
+		ledger_go.WithZemuAddress(address),
+		ledger_go.WithZemuKeepalive(keepalive.ClientParameters{Time: time.Minute}),
+	)
+
+	device, err := admin.Connect(0)
+	require.NoError(t, err)
+	defer device.Close()
+
+	// Status words are not transport failures
+	_, err = device.Exchange([]byte{ledgertest.ReferenceCLA, 0x7F, 0x00, 0x00, 0x00})
+	sw, ok := ledger_go.StatusWord(err)
+	assert.True(t, ok)
+	assert.Equal(t, uint16(ledger_go.SwInsNotSupported), sw)
+	assert.NotErrorIs(t, err, ledger_go.ErrEmulatorUnreachable)
+
+	server.Stop()
+	_, err = device.Exchange([]byte{ledgertest.ReferenceCLA, ledgertest.InsReply, 0x90, 0x00, 0x00})
+	assert.ErrorIs(t, err, ledger_go.ErrEmulatorUnreachable)
+	_, ok = ledger_go.StatusWord(err)
+	assert.False(t, ok)
+}
+
+func TestZemuTLS(t *testing.T) {
+	certificate, pool := selfSignedCertificate(t)
+	address := startZemuStandIn(t, ledgertest.NewReferenceApp(),
+		grpc.Creds(credentials.NewTLS(&tls.Config{Certificates: []tls.Certificate{certificate}})))
+
+	plaintext := ledger_go.NewLedgerAdminZemu(ledger_go.WithZemuAddress(address), ledger_go.WithZemuConnectTimeout(time.Second))
+	_, err := plaintext.Connect(0)
+	assert.ErrorIs(t, err, ledger_go.ErrEmulatorUnreachable)
+
+	admin := ledger_go.NewLedgerAdminZemu(
+		ledger_go.WithZemuAddress(address),
+		ledger_go.WithZemuTLS(&tls.Config{RootCAs: pool, ServerName: "127.0.0.1"}),
+	)
+	device, err := admin.Connect(0)
+	require.NoError(t, err)
+	defer device.Close()
+
+	response, err := device.Exchange([]byte{ledgertest.ReferenceCLA, ledgertest.InsReply, 0x90, 0x00, 0x01, 0x42})
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0x42}, response)
+}
+
+func TestZemuControl(t *testing.T) {
+	app := ledger_go.NewVirtualDevice()
+	app.Handle(0x55, 0x20, func(command *ledger_go.Command) ([]byte, uint16) {
+		if !app.Prompt(command) {
+			return nil, ledger_go.SwCommandNotAllowed
+		}
+		return []byte{0x01}, ledger_go.SwOK
+	})
+	admin := ledger_go.NewLedgerAdminZemu(ledger_go.WithZemuAddress(startZemuStandIn(t, app)))
+
+	connected, err := admin.Connect(0)
+	require.NoError(t, err)
+	device := connected.(*ledger_go.LedgerDeviceZemu)
+	defer device.Close()
+
+	confirm := func() <-chan error {
+		done := make(chan error, 1)
+		go func() {
+			_, err := device.Exchange([]byte{0x55, 0x20, 0x00, 0x00, 0x00})
+			done <- err
+		}()
+		return done
+	}
+
+	done := confirm()
+	screen, err := device.WaitForText("Review", 2*time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Review", "request"}, screen)
+
+	screen, err = device.PressRight()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"CLA 55 INS 20", "P1 00 P2 00"}, screen)
+
+	image, screen, err := device.Snapshot()
+	require.NoError(t, err)
+	assert.NotEmpty(t, image)
+	assert.Equal(t, []string{"CLA 55 INS 20", "P1 00 P2 00"}, screen)
+
+	require.NoError(t, device.Approve())
+	assert.NoError(t, <-done)
+
+	done = confirm()
+	require.NoError(t, device.Reject())
+	sw, _ := ledger_go.StatusWord(<-done)
+	assert.Equal(t, uint16(ledger_go.SwCommandNotAllowed), sw)
+
+	_, err = device.WaitForText("Review", 20*time.Millisecond)
+	assert.ErrorIs(t, err, ledger_go.ErrTextNotFound)
+
+	done = confirm()
+	_, err = device.WaitForText("Review", 2*time.Second)
+	require.NoError(t, err)
+	require.NoError(t, device.Reset())
+	assert.Error(t, <-done)
+
+	require.NoError(t, device.Close())
+	_, err = device.PressLeft()
+	assert.ErrorIs(t, err, ledger_go.ErrDeviceClosed)
+	assert.ErrorIs(t, device.Reset(), ledger_go.ErrDeviceClosed)
+}
+
+// selfSignedCertificate returns a certificate for 127.0.0.1 and a pool trusting it
+func selfSignedCertificate(t *testing.T) (tls.Certificate, *x509.CertPool) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "zemu"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	parsed, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	pool := x509.NewCertPool()
+	pool.AddCert(parsed)
+
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}, pool
+}
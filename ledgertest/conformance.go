@@ -0,0 +1,220 @@
+/*******************************************************************************
+*   (c) Zondax AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+********************************************************************************/
+
+package ledgertest
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	ledger_go "github.com/zondax/ledger-go"
+)
+
+// conformanceTimeout bounds each exchange, so a hanging backend fails instead of blocking the suite
+const conformanceTimeout = 10 * time.Second
+
+// Connector returns a connection to a device running the reference app.
+type Connector func() (ledger_go.LedgerDevice, error)
+
+// RunAdminConformance checks that admin follows the LedgerAdmin contract, then runs the
+// device checks on the device at deviceIndex, which must run the reference app.
+func RunAdminConformance(t *testing.T, admin ledger_go.LedgerAdmin, deviceIndex int) {
+	t.Run("Enumeration", func(t *testing.T) {
+		count := admin.CountDevices()
+		assert.Greater(t, count, deviceIndex)
+
+		_, err := admin.ListDevices()
+		assert.NoError(t, err)
+
+		_, err = admin.Connect(count)
+		assert.ErrorIs(t, err, ledger_go.ErrDeviceNotFound)
+		_, err = admin.Connect(-1)
+		assert.ErrorIs(t, err, ledger_go.ErrDeviceNotFound)
+	})
+
+	RunDeviceConformance(t, func() (ledger_go.LedgerDevice, error) {
+		return admin.Connect(deviceIndex)
+	})
+}
+
+// RunDeviceConformance checks that the devices returned by connect follow the LedgerDevice
+// contract: command validation, status words, large replies, concurrent use and Close.
+func RunDeviceConformance(t *testing.T, connect Connector) {
+	checks := []struct {
+		name  string
+		check func(t *testing.T, device ledger_go.LedgerDevice)
+	}{
+		{"Validation", checkValidation},
+		{"Echo", checkEcho},
+		{"StatusWords", checkStatusWords},
+		{"LargeResponse", checkLargeResponse},
+		{"Concurrency", checkConcurrency},
+		// Close goes last, as some backends hand out the same device on every connection
+		{"Close", checkClose},
+	}
+
+	for _, c := range checks {
+		t.Run(c.name, func(t *testing.T) {
+			device, err := connect()
+			require.NoError(t, err)
+			require.NotNil(t, device)
+
+			c.check(t, device)
+		})
+	}
+}
+
+type exchangeResult struct {
+	response []byte
+	err      error
+}
+
+// exchange fails the test when the device does not answer in time
+func exchange(t *testing.T, device ledger_go.LedgerDevice, command []byte) ([]byte, error) {
+	t.Helper()
+
+	done := make(chan exchangeResult, 1)
+	go func() {
+		response, err := device.Exchange(command)
+		done <- exchangeResult{response: response, err: err}
+	}()
+
+	select {
+	case result := <-done:
+		return result.response, result.err
+	case <-time.After(conformanceTimeout):
+		t.Fatalf("no reply to %x after %s", command, conformanceTimeout)
+		return nil, nil
+	}
+}
+
+func checkValidation(t *testing.T, device ledger_go.LedgerDevice) {
+	defer device.Close()
+
+	_, err := exchange(t, device, []byte{ReferenceCLA, InsReply, 0x90, 0x00})
+	assert.ErrorIs(t, err, ledger_go.ErrCommandTooShort)
+
+	_, err = exchange(t, device, []byte{ReferenceCLA, InsReply, 0x90, 0x00, 0x02, 0x01})
+	assert.ErrorIs(t, err, ledger_go.ErrCommandLengthMismatch)
+
+	_, err = exchange(t, device, []byte{ReferenceCLA, InsReply, 0x90, 0x00, 0x00, 0x01})
+	assert.ErrorIs(t, err, ledger_go.ErrCommandLengthMismatch)
+
+	// The device is still usable
+	response, err := exchange(t, device, replyCommand(ledger_go.SwOK, []byte{0x42}))
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0x42}, response)
+}
+
+func checkEcho(t *testing.T, device ledger_go.LedgerDevice) {
+	defer device.Close()
+
+	for _, payload := range echoPayloads() {
+		response, err := exchange(t, device, replyCommand(ledger_go.SwOK, payload))
+		require.NoError(t, err, "payload of %d bytes", len(payload))
+		assert.Equal(t, payload, append([]byte{}, response...), "payload of %d bytes", len(payload))
+	}
+}
+
+func checkStatusWords(t *testing.T, device ledger_go.LedgerDevice) {
+	defer device.Close()
+
+	_, err := exchange(t, device, replyCommand(ledger_go.SwConditionsNotSatisfied, nil))
+	var apduErr *ledger_go.APDUError
+	require.True(t, errors.As(err, &apduErr), "expected an APDUError, got %v", err)
+	assert.Equal(t, uint16(ledger_go.SwConditionsNotSatisfied), apduErr.Code)
+	assert.EqualError(t, err, ledger_go.ErrorMessage(ledger_go.SwConditionsNotSatisfied))
+
+	// Data sent along with an error status word is returned
+	response, err := exchange(t, device, replyCommand(ledger_go.SwCommandNotAllowed, []byte{0x01, 0x02}))
+	sw, ok := ledger_go.StatusWord(err)
+	assert.True(t, ok, "expected a status word, got %v", err)
+	assert.Equal(t, uint16(ledger_go.SwCommandNotAllowed), sw)
+	assert.Equal(t, []byte{0x01, 0x02}, response)
+
+	_, err = exchange(t, device, unknownCommand())
+	sw, ok = ledger_go.StatusWord(err)
+	assert.True(t, ok, "expected a status word, got %v", err)
+	assert.Equal(t, uint16(ledger_go.SwInsNotSupported), sw)
+}
+
+func checkLargeResponse(t *testing.T, device ledger_go.LedgerDevice) {
+	defer device.Close()
+
+	response, err := exchange(t, device, largeCommand(referenceLargeSize))
+	require.NoError(t, err)
+	assert.Equal(t, referencePattern(referenceLargeSize), response)
+}
+
+func checkConcurrency(t *testing.T, device ledger_go.LedgerDevice) {
+	defer device.Close()
+
+	const iterations = 3
+
+	var wg sync.WaitGroup
+	failures := make(chan string, referenceConcurrency*iterations)
+	for i := 0; i < referenceConcurrency; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			payload := concurrentPayload(i)
+			for j := 0; j < iterations; j++ {
+				response, err := device.Exchange(replyCommand(ledger_go.SwOK, payload))
+				switch {
+				case err != nil:
+					failures <- err.Error()
+				case string(response) != string(payload):
+					failures <- "replies were mixed up between concurrent exchanges"
+				}
+			}
+		}(i)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(conformanceTimeout):
+		t.Fatalf("concurrent exchanges did not complete after %s", conformanceTimeout)
+	}
+
+	close(failures)
+	for failure := range failures {
+		t.Error(failure)
+	}
+}
+
+func checkClose(t *testing.T, device ledger_go.LedgerDevice) {
+	_, err := exchange(t, device, replyCommand(ledger_go.SwOK, nil))
+	require.NoError(t, err)
+
+	require.NoError(t, device.Close())
+
+	_, err = exchange(t, device, replyCommand(ledger_go.SwOK, nil))
+	assert.ErrorIs(t, err, ledger_go.ErrDeviceClosed)
+
+	assert.NotPanics(t, func() { _ = device.Close() })
+}
@@ -0,0 +1,82 @@
+/*******************************************************************************
+*   (c) Zondax AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+********************************************************************************/
+
+// Package ledgertest provides mock devices and admins for testing code built on ledger-go.
+// It compiles in normal builds, so mocks can be used without the ledger_mock tag
+// and next to the real backends.
+package ledgertest
+
+import (
+	"strconv"
+
+	ledger_go "github.com/zondax/ledger-go"
+)
+
+// Cleanuper is implemented by *testing.T and *testing.B.
+type Cleanuper interface {
+	TestingT
+	Cleanup(func())
+}
+
+// NewAdmin returns an admin exposing the given devices, named "Mock device <index>".
+func NewAdmin(devices ...ledger_go.LedgerDevice) *Admin {
+	admin := &Admin{}
+	for i, device := range devices {
+		admin.AddDevice(DeviceName(i), device)
+	}
+	return admin
+}
+
+// DeviceName returns the name NewAdmin gives to the device at index.
+func DeviceName(index int) string {
+	return "Mock device " + strconv.Itoa(index)
+}
+
+// NewDevice returns a device answering each hex command with its raw hex reply, status word included.
+func NewDevice(replies map[string]string) *Device {
+	if replies == nil {
+		replies = make(map[string]string)
+	}
+	return &Device{commands: replies}
+}
+
+// NewScriptedDevice returns a scripted device whose expectations are asserted when the test ends.
+func NewScriptedDevice(t Cleanuper) *ScriptedDevice {
+	device := newScriptedDevice()
+	t.Cleanup(func() {
+		device.AssertExpectations(t)
+	})
+	return device
+}
+
+// NewReplay returns a device replaying the transcript at path in order,
+// failing the test on load errors and when exchanges are left over.
+func NewReplay(t Cleanuper, path string) *ledger_go.ReplayDevice {
+	t.Helper()
+
+	device, err := ledger_go.LoadReplayDevice(path, ledger_go.ReplayOrdered)
+	if err != nil {
+		t.Errorf("could not load transcript: %v", err)
+		return ledger_go.NewReplayDevice(nil, ledger_go.ReplayOrdered)
+	}
+
+	t.Cleanup(func() {
+		if remaining := device.Remaining(); remaining > 0 {
+			t.Errorf("%s: %d recorded exchanges were not replayed", path, remaining)
+		}
+	})
+	return device
+}
@@ -0,0 +1,67 @@
+/*******************************************************************************
+*   (c) Zondax AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+********************************************************************************/
+
+package ledgertest_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	ledger_go "github.com/zondax/ledger-go"
+	"github.com/zondax/ledger-go/ledgertest"
+)
+
+func TestAdmin(t *testing.T) {
+	device := ledgertest.NewDevice(map[string]string{
+		"b001000000": "0105424f4c4f5305322e312e30010a9000",
+	})
+
+	var admin ledger_go.LedgerAdmin = ledgertest.NewAdmin(device)
+	names, err := admin.ListDevices()
+	require.NoError(t, err)
+	assert.Equal(t, []string{ledgertest.DeviceName(0)}, names)
+
+	connected, err := admin.Connect(0)
+	require.NoError(t, err)
+
+	info, err := ledger_go.GetAppAndVersion(connected)
+	require.NoError(t, err)
+	assert.True(t, info.IsDashboard())
+}
+
+func TestScriptedDevice(t *testing.T) {
+	device := ledgertest.NewScriptedDevice(t)
+	device.Expect(0x55, ledger_go.InsSign, ledgertest.Any, ledgertest.Any).ReplyData("3044").Times(2)
+
+	client := ledger_go.NewAppClient(device, ledger_go.AppConfig{CLA: 0x55})
+	signature, err := client.Sign([]uint32{44 | 0x80000000, 118 | 0x80000000, 0x80000000, 0, 0}, []byte("message"))
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0x30, 0x44}, signature)
+}
+
+func TestReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session.apdu")
+	require.NoError(t, os.WriteFile(path, []byte("=> e001000000\n<= 019000\n"), 0o600))
+
+	device := ledgertest.NewReplay(t, path)
+	response, err := device.Exchange([]byte{0xe0, 0x01, 0x00, 0x00, 0x00})
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0x01}, response)
+}
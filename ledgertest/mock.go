@@ -0,0 +1,216 @@
+/*******************************************************************************
+*   (c) Zondax AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+********************************************************************************/
+
+package ledgertest
+
+import (
+	"encoding/hex"
+	"fmt"
+	"sync"
+
+	ledger_go "github.com/zondax/ledger-go"
+)
+
+type mockDeviceEntry struct {
+	name       string
+	device     ledger_go.LedgerDevice
+	connectErr error
+}
+
+// Admin manages a list of named mock devices.
+// Connect returns the same device instance every time, so scripted replies are kept,
+// and reopens mock devices that were closed.
+type Admin struct {
+	mu      sync.Mutex
+	devices []*mockDeviceEntry
+}
+
+// Device answers commands with fixed replies.
+type Device struct {
+	mu       sync.Mutex
+	commands map[string]string
+	closed   bool
+}
+
+// AddDevice appends a device and returns its index.
+func (admin *Admin) AddDevice(name string, device ledger_go.LedgerDevice) int {
+	admin.mu.Lock()
+	defer admin.mu.Unlock()
+
+	admin.devices = append(admin.devices, &mockDeviceEntry{name: name, device: device})
+	return len(admin.devices) - 1
+}
+
+// RemoveDevice removes the device at deviceIndex, simulating an unplugged device.
+func (admin *Admin) RemoveDevice(deviceIndex int) error {
+	admin.mu.Lock()
+	defer admin.mu.Unlock()
+
+	if _, err := admin.entry(deviceIndex); err != nil {
+		return err
+	}
+	admin.devices = append(admin.devices[:deviceIndex], admin.devices[deviceIndex+1:]...)
+	return nil
+}
+
+// SetConnectError makes Connect fail with err for the device at deviceIndex, nil restores it.
+func (admin *Admin) SetConnectError(deviceIndex int, err error) error {
+	admin.mu.Lock()
+	defer admin.mu.Unlock()
+
+	entry, lookupErr := admin.entry(deviceIndex)
+	if lookupErr != nil {
+		return lookupErr
+	}
+	entry.connectErr = err
+	return nil
+}
+
+// SetBusy makes Connect fail with ledger_go.ErrDeviceBusy for the device at deviceIndex.
+func (admin *Admin) SetBusy(deviceIndex int, busy bool) error {
+	var err error
+	if busy {
+		err = fmt.Errorf("%w: mock device %d held by another process", ledger_go.ErrDeviceBusy, deviceIndex)
+	}
+	return admin.SetConnectError(deviceIndex, err)
+}
+
+// Device returns the device at deviceIndex without connecting, e.g. to script it.
+func (admin *Admin) Device(deviceIndex int) (ledger_go.LedgerDevice, error) {
+	admin.mu.Lock()
+	defer admin.mu.Unlock()
+
+	entry, err := admin.entry(deviceIndex)
+	if err != nil {
+		return nil, err
+	}
+	return entry.device, nil
+}
+
+func (admin *Admin) entry(deviceIndex int) (*mockDeviceEntry, error) {
+	if deviceIndex < 0 || deviceIndex >= len(admin.devices) {
+		return nil, fmt.Errorf("mock %w (idx %d): %d devices available", ledger_go.ErrDeviceNotFound, deviceIndex, len(admin.devices))
+	}
+	return admin.devices[deviceIndex], nil
+}
+
+func (admin *Admin) ListDevices() ([]string, error) {
+	admin.mu.Lock()
+	defer admin.mu.Unlock()
+
+	names := make([]string, 0, len(admin.devices))
+	for _, entry := range admin.devices {
+		names = append(names, entry.name)
+	}
+	return names, nil
+}
+
+func (admin *Admin) CountDevices() int {
+	admin.mu.Lock()
+	defer admin.mu.Unlock()
+
+	return len(admin.devices)
+}
+
+func (admin *Admin) Connect(deviceIndex int) (ledger_go.LedgerDevice, error) {
+	admin.mu.Lock()
+	defer admin.mu.Unlock()
+
+	entry, err := admin.entry(deviceIndex)
+	if err != nil {
+		return nil, err
+	}
+	if entry.connectErr != nil {
+		return nil, entry.connectErr
+	}
+	if device, ok := entry.device.(reopener); ok {
+		device.Reopen()
+	}
+	return entry.device, nil
+}
+
+// reopener is implemented by devices that can be connected again after Close,
+// such as Device, ScriptedDevice and ledger_go.VirtualDevice.
+type reopener interface {
+	Reopen()
+}
+
+// Exchange validates the command and processes the scripted reply, including its
+// trailing status word, the same way the HID and Zemu transports do.
+func (d *Device) Exchange(command []byte) ([]byte, error) {
+	if err := ledger_go.ValidateCommand(command); err != nil {
+		return nil, err
+	}
+
+	d.mu.Lock()
+	closed := d.closed
+	hexCommand := hex.EncodeToString(command)
+	reply, ok := d.commands[hexCommand]
+	d.mu.Unlock()
+
+	if closed {
+		return nil, ledger_go.ErrDeviceClosed
+	}
+	if !ok {
+		return nil, fmt.Errorf("unknown command: %s", hexCommand)
+	}
+
+	response, err := hex.DecodeString(reply)
+	if err != nil {
+		return nil, err
+	}
+
+	return ledger_go.ParseResponse(response)
+}
+
+// SetCommandReplies sets the raw replies, as hex data followed by the status word, for each hex command.
+func (d *Device) SetCommandReplies(commands map[string]string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.commands = commands
+}
+
+// SetCommandStatus makes the hex command fail with the given status word.
+func (d *Device) SetCommandStatus(command string, sw uint16) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.commands == nil {
+		d.commands = make(map[string]string)
+	}
+	d.commands[command] = fmt.Sprintf("%04x", sw)
+}
+
+// ClearCommands removes every reply.
+func (d *Device) ClearCommands() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.commands = make(map[string]string)
+}
+
+// Close makes further exchanges fail with ledger_go.ErrDeviceClosed until the device is connected again.
+func (d *Device) Close() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.closed = true
+	return nil
+}
+
+// Reopen lets a closed device be used again.
+func (d *Device) Reopen() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.closed = false
+}
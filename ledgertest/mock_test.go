@@ -0,0 +1,103 @@
+/*******************************************************************************
+*   (c) Zondax AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+********************************************************************************/
+
+package ledgertest
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	ledger_go "github.com/zondax/ledger-go"
+)
+
+func TestAdminDevices(t *testing.T) {
+	admin := &Admin{}
+	assert.Equal(t, 0, admin.CountDevices())
+
+	_, err := admin.Connect(0)
+	assert.ErrorIs(t, err, ledger_go.ErrDeviceNotFound)
+
+	first := NewDevice(nil)
+	second := NewDevice(nil)
+	assert.Equal(t, 0, admin.AddDevice("Nano S", first))
+	assert.Equal(t, 1, admin.AddDevice("Nano X", second))
+
+	names, err := admin.ListDevices()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Nano S", "Nano X"}, names)
+	assert.Equal(t, 2, admin.CountDevices())
+
+	first.SetCommandReplies(map[string]string{"e001000000": "019000"})
+
+	// Reconnecting returns the same instance, keeping scripted replies
+	for i := 0; i < 2; i++ {
+		device, err := admin.Connect(0)
+		require.NoError(t, err)
+		assert.Same(t, first, device)
+
+		response, err := device.Exchange([]byte{0xE0, 0x01, 0, 0, 0})
+		require.NoError(t, err)
+		assert.Equal(t, []byte{0x01}, response)
+		require.NoError(t, device.Close())
+	}
+
+	device, err := admin.Connect(1)
+	require.NoError(t, err)
+	assert.Same(t, second, device)
+
+	_, err = admin.Connect(2)
+	assert.ErrorIs(t, err, ledger_go.ErrDeviceNotFound)
+	_, err = admin.Connect(-1)
+	assert.ErrorIs(t, err, ledger_go.ErrDeviceNotFound)
+}
+
+func TestAdminErrors(t *testing.T) {
+	admin := &Admin{}
+	admin.AddDevice("Nano S", NewDevice(nil))
+
+	require.NoError(t, admin.SetBusy(0, true))
+	_, err := admin.Connect(0)
+	assert.ErrorIs(t, err, ledger_go.ErrDeviceBusy)
+
+	require.NoError(t, admin.SetBusy(0, false))
+	_, err = admin.Connect(0)
+	assert.NoError(t, err)
+
+	failure := fmt.Errorf("permission denied")
+	require.NoError(t, admin.SetConnectError(0, failure))
+	_, err = admin.Connect(0)
+	assert.ErrorIs(t, err, failure)
+
+	assert.ErrorIs(t, admin.SetBusy(3, true), ledger_go.ErrDeviceNotFound)
+
+	require.NoError(t, admin.RemoveDevice(0))
+	assert.Equal(t, 0, admin.CountDevices())
+	assert.ErrorIs(t, admin.RemoveDevice(0), ledger_go.ErrDeviceNotFound)
+}
+
+func TestDeviceStatusAfterNilReplies(t *testing.T) {
+	device := NewDevice(nil)
+	device.SetCommandReplies(nil)
+	device.SetCommandStatus("e001000000", ledger_go.SwInsNotSupported)
+
+	_, err := device.Exchange([]byte{0xE0, 0x01, 0, 0, 0})
+	sw, ok := ledger_go.StatusWord(err)
+	assert.True(t, ok)
+	assert.Equal(t, uint16(ledger_go.SwInsNotSupported), sw)
+}
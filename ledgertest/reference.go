@@ -0,0 +1,128 @@
+/*******************************************************************************
+*   (c) Zondax AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+********************************************************************************/
+
+package ledgertest
+
+import (
+	"bytes"
+	"encoding/hex"
+
+	ledger_go "github.com/zondax/ledger-go"
+)
+
+// Reference app instructions
+const (
+	ReferenceCLA = 0x80
+
+	// InsReply answers with the command data and the status word taken from P1 and P2
+	InsReply = 0x01
+	// InsLarge answers with P1 * 256 + P2 bytes
+	InsLarge = 0x02
+)
+
+const (
+	referenceLargeSize   = 1000
+	referenceConcurrency = 4
+)
+
+// NewReferenceApp returns the virtual app the conformance suite is run against.
+func NewReferenceApp() *ledger_go.VirtualDevice {
+	device := ledger_go.NewVirtualDevice()
+
+	device.Handle(ReferenceCLA, InsReply, func(command *ledger_go.Command) ([]byte, uint16) {
+		return command.Data, uint16(command.P1)<<8 | uint16(command.P2)
+	})
+
+	device.Handle(ReferenceCLA, InsLarge, func(command *ledger_go.Command) ([]byte, uint16) {
+		return referencePattern(int(command.P1)<<8 | int(command.P2)), ledger_go.SwOK
+	})
+
+	return device
+}
+
+func referencePattern(size int) []byte {
+	data := make([]byte, size)
+	for i := range data {
+		data[i] = byte(i % 251)
+	}
+	return data
+}
+
+func replyCommand(sw uint16, data []byte) []byte {
+	return append([]byte{ReferenceCLA, InsReply, byte(sw >> 8), byte(sw), byte(len(data))}, data...)
+}
+
+func largeCommand(size int) []byte {
+	return []byte{ReferenceCLA, InsLarge, byte(size >> 8), byte(size), 0x00}
+}
+
+func unknownCommand() []byte {
+	return []byte{ReferenceCLA, 0x7F, 0x00, 0x00, 0x00}
+}
+
+// echoPayloads are the payloads sent back by the reference app
+func echoPayloads() [][]byte {
+	full := make([]byte, 255)
+	for i := range full {
+		full[i] = byte(i)
+	}
+	return [][]byte{{}, {0x42}, full}
+}
+
+// concurrentPayload is the payload sent by goroutine i in the concurrency check
+func concurrentPayload(i int) []byte {
+	return bytes.Repeat([]byte{byte(0xC0 + i)}, 16+i)
+}
+
+// ReferenceCommands returns every valid command sent by the conformance suite.
+func ReferenceCommands() [][]byte {
+	var commands [][]byte
+	for _, payload := range echoPayloads() {
+		commands = append(commands, replyCommand(ledger_go.SwOK, payload))
+	}
+	for i := 0; i < referenceConcurrency; i++ {
+		commands = append(commands, replyCommand(ledger_go.SwOK, concurrentPayload(i)))
+	}
+
+	commands = append(commands,
+		replyCommand(ledger_go.SwConditionsNotSatisfied, nil),
+		replyCommand(ledger_go.SwCommandNotAllowed, []byte{0x01, 0x02}),
+		largeCommand(referenceLargeSize),
+		unknownCommand(),
+	)
+	return commands
+}
+
+// ReferenceReplies returns the raw replies of the reference app to ReferenceCommands,
+// keyed by hex command, for backends that can only serve canned replies.
+func ReferenceReplies() map[string]string {
+	app := NewReferenceApp()
+	replies := make(map[string]string)
+	for _, command := range ReferenceCommands() {
+		replies[hex.EncodeToString(command)] = hex.EncodeToString(app.Process(command))
+	}
+	return replies
+}
+
+// ReferenceTranscript returns the exchanges of the reference app, to be replayed with ReplayKeyed.
+func ReferenceTranscript() []ledger_go.TranscriptEntry {
+	app := NewReferenceApp()
+	var entries []ledger_go.TranscriptEntry
+	for _, command := range ReferenceCommands() {
+		entries = append(entries, ledger_go.TranscriptEntry{Command: command, Response: app.Process(command)})
+	}
+	return entries
+}
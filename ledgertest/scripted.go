@@ -0,0 +1,306 @@
+/*******************************************************************************
+*   (c) Zondax AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+********************************************************************************/
+
+package ledgertest
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+
+	ledger_go "github.com/zondax/ledger-go"
+)
+
+// Any matches every value of a header byte in ScriptedDevice.Expect
+const Any = -1
+
+// TestingT is the subset of *testing.T used by ScriptedDevice.
+type TestingT interface {
+	Errorf(format string, args ...interface{})
+	Helper()
+}
+
+type scriptedReply struct {
+	data []byte
+	err  error
+}
+
+// Expectation is a command expected by a ScriptedDevice and the replies to send.
+type Expectation struct {
+	description string
+	match       func(command []byte) bool
+	replies     []scriptedReply
+	times       int
+	anyTimes    bool
+	calls       int
+}
+
+// Reply adds a raw reply given in hex, made of the data followed by the status word.
+// Successive calls get successive replies, the last one being repeated.
+func (e *Expectation) Reply(hexReply string) *Expectation {
+	data, err := hex.DecodeString(strings.ReplaceAll(hexReply, " ", ""))
+	if err != nil {
+		panic(fmt.Sprintf("invalid reply %q: %v", hexReply, err))
+	}
+	e.replies = append(e.replies, scriptedReply{data: data})
+	return e
+}
+
+// ReplyData adds a successful reply carrying the hex data.
+func (e *Expectation) ReplyData(hexData string) *Expectation {
+	return e.Reply(hexData + "9000")
+}
+
+// ReplyStatus adds a reply with no data and the given status word.
+func (e *Expectation) ReplyStatus(sw uint16) *Expectation {
+	return e.Reply(fmt.Sprintf("%04x", sw))
+}
+
+// ReplyError adds a reply that fails with err.
+func (e *Expectation) ReplyError(err error) *Expectation {
+	e.replies = append(e.replies, scriptedReply{err: err})
+	return e
+}
+
+// Times sets how many calls are expected, one by default.
+func (e *Expectation) Times(n int) *Expectation {
+	e.times = n
+	e.anyTimes = false
+	return e
+}
+
+// AnyTimes allows any number of calls, including none.
+func (e *Expectation) AnyTimes() *Expectation {
+	e.anyTimes = true
+	return e
+}
+
+func (e *Expectation) available() bool {
+	return e.anyTimes || e.calls < e.times
+}
+
+func (e *Expectation) satisfied() bool {
+	return e.anyTimes || e.calls >= e.times
+}
+
+func (e *Expectation) reply() ([]byte, error) {
+	index := e.calls
+	e.calls++
+
+	if len(e.replies) == 0 {
+		return []byte{0x90, 0x00}, nil
+	}
+	if index >= len(e.replies) {
+		index = len(e.replies) - 1
+	}
+
+	r := e.replies[index]
+	return r.data, r.err
+}
+
+// ScriptedDevice is a LedgerDevice answering commands according to expectations.
+// Expectations are matched in order unless SetOrdered(false) is called.
+// Replies go through the same validation and status word handling as real transports.
+type ScriptedDevice struct {
+	mu           sync.Mutex
+	expectations []*Expectation
+	ordered      bool
+	next         int
+	unexpected   []string
+	closed       bool
+}
+
+func newScriptedDevice() *ScriptedDevice {
+	return &ScriptedDevice{ordered: true}
+}
+
+// SetOrdered selects whether expectations must be met in the order they were declared.
+func (m *ScriptedDevice) SetOrdered(ordered bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.ordered = ordered
+}
+
+// Expect adds an expectation on the command header. Use Any as a wildcard.
+func (m *ScriptedDevice) Expect(cla, ins, p1, p2 int) *Expectation {
+	header := []int{cla, ins, p1, p2}
+	parts := make([]string, len(header))
+	for i, value := range header {
+		parts[i] = "??"
+		if value != Any {
+			parts[i] = fmt.Sprintf("%02x", value)
+		}
+	}
+
+	return m.ExpectMatch(strings.Join(parts, " "), func(command []byte) bool {
+		if len(command) < len(header) {
+			return false
+		}
+		for i, value := range header {
+			if value != Any && command[i] != byte(value) {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+// ExpectCommand adds an expectation on the whole command given in hex,
+// where "??" matches any byte and a trailing "*" matches any remaining bytes.
+func (m *ScriptedDevice) ExpectCommand(pattern string) *Expectation {
+	text := strings.ToLower(strings.ReplaceAll(pattern, " ", ""))
+	prefixOnly := strings.HasSuffix(text, "*")
+	text = strings.TrimSuffix(text, "*")
+
+	if len(text)%2 != 0 {
+		panic(fmt.Sprintf("invalid command pattern %q", pattern))
+	}
+
+	expected := make([]byte, len(text)/2)
+	wildcard := make([]bool, len(text)/2)
+	for i := range expected {
+		pair := text[2*i : 2*i+2]
+		if pair == "??" {
+			wildcard[i] = true
+			continue
+		}
+		if _, err := hex.Decode(expected[i:i+1], []byte(pair)); err != nil {
+			panic(fmt.Sprintf("invalid command pattern %q: %v", pattern, err))
+		}
+	}
+
+	return m.ExpectMatch(pattern, func(command []byte) bool {
+		if len(command) < len(expected) || (!prefixOnly && len(command) != len(expected)) {
+			return false
+		}
+		for i := range expected {
+			if !wildcard[i] && command[i] != expected[i] {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+// ExpectData adds an expectation on the header and the exact command data.
+func (m *ScriptedDevice) ExpectData(cla, ins, p1, p2 int, data []byte) *Expectation {
+	header := m.Expect(cla, ins, p1, p2)
+	headerMatch := header.match
+	header.description += fmt.Sprintf(" data %x", data)
+	header.match = func(command []byte) bool {
+		return headerMatch(command) && len(command) >= 5 && bytes.Equal(command[5:], data)
+	}
+	return header
+}
+
+// ExpectMatch adds an expectation using an arbitrary predicate.
+func (m *ScriptedDevice) ExpectMatch(description string, match func(command []byte) bool) *Expectation {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	expectation := &Expectation{description: description, match: match, times: 1}
+	m.expectations = append(m.expectations, expectation)
+	return expectation
+}
+
+func (m *ScriptedDevice) find(command []byte) *Expectation {
+	if !m.ordered {
+		for _, e := range m.expectations {
+			if e.available() && e.match(command) {
+				return e
+			}
+		}
+		return nil
+	}
+
+	for m.next < len(m.expectations) {
+		e := m.expectations[m.next]
+		if e.available() && e.match(command) {
+			return e
+		}
+		if !e.satisfied() {
+			return nil
+		}
+		m.next++
+	}
+	return nil
+}
+
+func (m *ScriptedDevice) Exchange(command []byte) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.closed {
+		return nil, ledger_go.ErrDeviceClosed
+	}
+
+	if err := ledger_go.ValidateCommand(command); err != nil {
+		return nil, err
+	}
+
+	expectation := m.find(command)
+	if expectation == nil {
+		hexCommand := hex.EncodeToString(command)
+		m.unexpected = append(m.unexpected, hexCommand)
+		return nil, fmt.Errorf("unexpected command: %s", hexCommand)
+	}
+
+	response, err := expectation.reply()
+	if err != nil {
+		return nil, err
+	}
+
+	return ledger_go.ParseResponse(response)
+}
+
+func (m *ScriptedDevice) Close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.closed = true
+	return nil
+}
+
+// Reopen lets a closed device be used again, called when an Admin connects it.
+func (m *ScriptedDevice) Reopen() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.closed = false
+}
+
+// AssertExpectations reports unexpected commands and expectations that were not met.
+func (m *ScriptedDevice) AssertExpectations(t TestingT) bool {
+	t.Helper()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	ok := true
+	for _, command := range m.unexpected {
+		t.Errorf("unexpected command: %s", command)
+		ok = false
+	}
+
+	for _, e := range m.expectations {
+		if !e.satisfied() {
+			t.Errorf("expected command %s: called %d of %d times", e.description, e.calls, e.times)
+			ok = false
+		}
+	}
+
+	return ok
+}
@@ -0,0 +1,167 @@
+/*******************************************************************************
+*   (c) Zondax AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+********************************************************************************/
+
+package ledgertest
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	ledger_go "github.com/zondax/ledger-go"
+)
+
+var testPath = []uint32{44 | 0x80000000, 118 | 0x80000000, 0x80000000, 0, 0}
+
+// fakeT records failures reported through TestingT
+type fakeT struct {
+	errors []string
+}
+
+func (f *fakeT) Errorf(format string, args ...interface{}) {
+	f.errors = append(f.errors, fmt.Sprintf(format, args...))
+}
+
+func (f *fakeT) Helper() {}
+
+func TestScriptedDeviceOrdered(t *testing.T) {
+	mock := newScriptedDevice()
+	mock.ExpectCommand("b001000000").ReplyData("0105424f4c4f5305322e312e30")
+	mock.Expect(0x55, 0x02, Any, Any).ReplyData("").Times(2)
+	mock.Expect(0x55, 0x02, ledger_go.PayloadLast, Any).ReplyData("aabb")
+
+	_, err := mock.Exchange([]byte{0x55, 0x02, 0x00, 0x00, 0x00})
+	assert.Error(t, err, "commands must follow the declared order")
+
+	info, err := ledger_go.GetAppAndVersion(mock)
+	require.NoError(t, err)
+	assert.Equal(t, "BOLOS", info.Name)
+
+	for i := 0; i < 2; i++ {
+		_, err = mock.Exchange([]byte{0x55, 0x02, 0x01, 0x00, 0x00})
+		require.NoError(t, err)
+	}
+
+	response, err := mock.Exchange([]byte{0x55, 0x02, 0x02, 0x00, 0x00})
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0xaa, 0xbb}, response)
+
+	ft := &fakeT{}
+	assert.False(t, mock.AssertExpectations(ft))
+	assert.Equal(t, []string{"unexpected command: 5502000000"}, ft.errors)
+}
+
+func TestScriptedDevicePerCallReplies(t *testing.T) {
+	mock := newScriptedDevice()
+	failure := errors.New("boom")
+	mock.ExpectCommand("e0 01 ?? ?? 00").ReplyData("01").ReplyData("02").ReplyError(failure).AnyTimes()
+
+	var replies []byte
+	for i := 0; i < 2; i++ {
+		response, err := mock.Exchange([]byte{0xe0, 0x01, byte(i), 0x00, 0x00})
+		require.NoError(t, err)
+		replies = append(replies, response...)
+	}
+	assert.Equal(t, []byte{0x01, 0x02}, replies)
+
+	for i := 0; i < 2; i++ {
+		_, err := mock.Exchange([]byte{0xe0, 0x01, 0x00, 0x00, 0x00})
+		assert.ErrorIs(t, err, failure)
+	}
+
+	assert.True(t, mock.AssertExpectations(t))
+}
+
+func TestScriptedDeviceUnordered(t *testing.T) {
+	mock := newScriptedDevice()
+	mock.SetOrdered(false)
+	mock.ExpectCommand("e0*").ReplyData("01")
+	mock.ExpectData(0x55, 0x01, 0x00, Any, []byte{0x01, 0x02}).ReplyData("02")
+
+	response, err := mock.Exchange([]byte{0x55, 0x01, 0x00, 0x07, 0x02, 0x01, 0x02})
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0x02}, response)
+
+	_, err = mock.Exchange([]byte{0x55, 0x01, 0x00, 0x07, 0x02, 0x01, 0x03})
+	assert.Error(t, err)
+
+	response, err = mock.Exchange([]byte{0xe0, 0x01, 0x00, 0x00, 0x00})
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0x01}, response)
+}
+
+func TestScriptedDeviceUnmetExpectations(t *testing.T) {
+	mock := newScriptedDevice()
+	mock.ExpectMatch("any sign", func(command []byte) bool { return command[1] == 0x02 }).Times(3)
+
+	_, err := mock.Exchange([]byte{0x55, 0x02, 0x00, 0x00, 0x00})
+	require.NoError(t, err)
+
+	ft := &fakeT{}
+	assert.False(t, mock.AssertExpectations(ft))
+	assert.Equal(t, []string{"expected command any sign: called 1 of 3 times"}, ft.errors)
+
+	require.NoError(t, mock.Close())
+	_, err = mock.Exchange([]byte{0x55, 0x02, 0x00, 0x00, 0x00})
+	assert.Error(t, err)
+}
+
+func TestScriptedDeviceAppClient(t *testing.T) {
+	mock := newScriptedDevice()
+	mock.Expect(0x55, ledger_go.InsSign, ledger_go.PayloadInit, 0).ReplyData("")
+	mock.Expect(0x55, ledger_go.InsSign, ledger_go.PayloadLast, 0).ReplyData("3044")
+
+	client := ledger_go.NewAppClient(mock, ledger_go.AppConfig{CLA: 0x55})
+	signature, err := client.Sign(testPath, []byte("message"))
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0x30, 0x44}, signature)
+
+	mock.AssertExpectations(t)
+}
+
+func TestScriptedDeviceStatusWords(t *testing.T) {
+	mock := newScriptedDevice()
+	mock.Expect(0x55, ledger_go.InsSign, Any, Any).ReplyStatus(ledger_go.SwCommandNotAllowed)
+	mock.Expect(0x55, ledger_go.InsGetVersion, Any, Any).Reply("01").AnyTimes()
+
+	_, err := mock.Exchange([]byte{0x55, ledger_go.InsSign, 0x00, 0x00, 0x00})
+	assert.EqualError(t, err, ledger_go.ErrorMessage(ledger_go.SwCommandNotAllowed))
+
+	_, err = mock.Exchange([]byte{0x55, ledger_go.InsGetVersion, 0x00, 0x00, 0x00})
+	assert.ErrorIs(t, err, ledger_go.ErrResponseTooShort)
+
+	_, err = mock.Exchange([]byte{0x55, ledger_go.InsGetVersion, 0x00, 0x00, 0x01})
+	assert.ErrorIs(t, err, ledger_go.ErrCommandLengthMismatch)
+}
+
+func TestScriptedDeviceReconnect(t *testing.T) {
+	device := NewScriptedDevice(t)
+	device.ExpectCommand("e001000000").ReplyData("01")
+
+	admin := NewAdmin(device)
+	require.NoError(t, device.Close())
+	_, err := device.Exchange([]byte{0xe0, 0x01, 0x00, 0x00, 0x00})
+	assert.ErrorIs(t, err, ledger_go.ErrDeviceClosed)
+
+	connected, err := admin.Connect(0)
+	require.NoError(t, err)
+	response, err := connected.Exchange([]byte{0xe0, 0x01, 0x00, 0x00, 0x00})
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0x01}, response)
+}
@@ -0,0 +1,248 @@
+/*******************************************************************************
+*   (c) Zondax AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+********************************************************************************/
+
+package ledger_go
+
+// APDU script format
+//
+// Scripts list the commands to send, in hex, one per line:
+//
+//	# Version, any data followed by 9000
+//	e000000000 => .*9000
+//	# Unknown instruction
+//	e0ff000000 => 6d00
+//	e001000000
+//
+// A command may be followed by "=>" and a regular expression that the raw reply in hex,
+// status word included, must match entirely. Without one, the reply must end with 9000.
+// Spaces are ignored in both, and the expression is case insensitive. "#" starts a
+// comment, on its own line or after a command. Blank lines are ignored.
+
+import (
+	"bufio"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const (
+	scriptExpectSeparator = "=>"
+	scriptCommentPrefix   = "#"
+
+	// scriptDefaultExpectation accepts any data followed by SwOK
+	scriptDefaultExpectation = ".*9000"
+)
+
+const (
+	ErrMsgInvalidScript  = "invalid APDU script"
+	ErrMsgScriptMismatch = "unexpected reply"
+)
+
+var (
+	ErrInvalidScript  = errors.New(ErrMsgInvalidScript)
+	ErrScriptMismatch = errors.New(ErrMsgScriptMismatch)
+)
+
+// ScriptStep is a command of an APDU script with the reply it expects.
+type ScriptStep struct {
+	Line    int
+	Command *Command
+	// Expected matches the raw reply in hex, status word included
+	Expected *regexp.Regexp
+	// Expectation is the expression as written, empty for the default one
+	Expectation string
+}
+
+// ParseScript reads the steps of an APDU script. Commands are checked with ParseCommand.
+func ParseScript(r io.Reader) ([]ScriptStep, error) {
+	var steps []ScriptStep
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+
+		line := scanner.Text()
+		if i := strings.Index(line, scriptCommentPrefix); i >= 0 {
+			line = line[:i]
+		}
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		step, err := parseScriptLine(line)
+		if err != nil {
+			return nil, fmt.Errorf("%w: line %d: %w", ErrInvalidScript, lineNumber, err)
+		}
+		step.Line = lineNumber
+		steps = append(steps, step)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return steps, nil
+}
+
+func parseScriptLine(line string) (ScriptStep, error) {
+	commandHex, expectation, hasExpectation := strings.Cut(line, scriptExpectSeparator)
+
+	raw, err := hex.DecodeString(removeSpaces(commandHex))
+	if err != nil {
+		return ScriptStep{}, err
+	}
+	command, err := ParseCommand(raw)
+	if err != nil {
+		return ScriptStep{}, err
+	}
+
+	step := ScriptStep{Command: command}
+	pattern := scriptDefaultExpectation
+	if hasExpectation {
+		step.Expectation = removeSpaces(expectation)
+		if step.Expectation == "" {
+			return ScriptStep{}, errors.New("empty expectation")
+		}
+		pattern = step.Expectation
+	}
+
+	step.Expected, err = regexp.Compile("^(?i:" + pattern + ")$")
+	if err != nil {
+		return ScriptStep{}, err
+	}
+	return step, nil
+}
+
+func removeSpaces(s string) string {
+	return strings.Join(strings.Fields(s), "")
+}
+
+// ScriptOptions controls how a script is run.
+type ScriptOptions struct {
+	// ContinueOnMismatch runs the remaining steps after a failure instead of stopping
+	ContinueOnMismatch bool
+}
+
+// ScriptResult is the outcome of a step.
+type ScriptResult struct {
+	Step ScriptStep
+	// Response is the raw reply, status word included, when the device answered
+	Response []byte
+	// Err is set when the step failed, wrapping ErrScriptMismatch when the reply was unexpected
+	Err     error
+	Elapsed time.Duration
+}
+
+// Passed reports whether the reply matched the expectation.
+func (r ScriptResult) Passed() bool {
+	return r.Err == nil
+}
+
+// ScriptReport lists the results of the steps that were run.
+type ScriptReport struct {
+	Results []ScriptResult
+	// Skipped counts the steps left after stopping on a failure
+	Skipped int
+}
+
+// Failed returns the number of failed steps.
+func (r *ScriptReport) Failed() int {
+	failed := 0
+	for _, result := range r.Results {
+		if !result.Passed() {
+			failed++
+		}
+	}
+	return failed
+}
+
+// Passed reports whether every step was run and passed.
+func (r *ScriptReport) Passed() bool {
+	return r.Failed() == 0 && r.Skipped == 0
+}
+
+// Write prints a line per step followed by a summary.
+func (r *ScriptReport) Write(w io.Writer) error {
+	var sb strings.Builder
+	for _, result := range r.Results {
+		status := "PASS"
+		if !result.Passed() {
+			status = "FAIL"
+		}
+		fmt.Fprintf(&sb, "%s line %d: %x", status, result.Step.Line, result.Step.Command.Bytes())
+		if result.Response != nil {
+			fmt.Fprintf(&sb, " => %x", result.Response)
+		}
+		if result.Err != nil {
+			fmt.Fprintf(&sb, ": %v", result.Err)
+		}
+		sb.WriteString("\n")
+	}
+
+	fmt.Fprintf(&sb, "%d passed, %d failed, %d skipped\n", len(r.Results)-r.Failed(), r.Failed(), r.Skipped)
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// RunScript sends the steps to device in order and checks the replies. Status words other
+// than 9000 are replies like any other, so only the expectations decide whether a step passes.
+func RunScript(device LedgerDevice, steps []ScriptStep, options ScriptOptions) *ScriptReport {
+	report := &ScriptReport{}
+
+	for i, step := range steps {
+		result := runScriptStep(device, step)
+		report.Results = append(report.Results, result)
+
+		if !result.Passed() && !options.ContinueOnMismatch {
+			report.Skipped = len(steps) - i - 1
+			break
+		}
+	}
+	return report
+}
+
+func runScriptStep(device LedgerDevice, step ScriptStep) ScriptResult {
+	result := ScriptResult{Step: step}
+
+	start := time.Now()
+	response, err := device.Exchange(step.Command.Bytes())
+	result.Elapsed = time.Since(start)
+
+	raw, ok := rawResponse(response, err)
+	if !ok {
+		result.Err = err
+		return result
+	}
+	result.Response = raw
+
+	if !step.Expected.MatchString(hex.EncodeToString(raw)) {
+		expectation := step.Expectation
+		if expectation == "" {
+			expectation = scriptDefaultExpectation
+		}
+		result.Err = fmt.Errorf("%w, expected %s", ErrScriptMismatch, expectation)
+		if err != nil {
+			result.Err = fmt.Errorf("%w: %w", result.Err, err)
+		}
+	}
+	return result
+}
@@ -0,0 +1,130 @@
+/*******************************************************************************
+*   (c) Zondax AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+********************************************************************************/
+
+package ledger_go
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testScript = `
+# Version of the virtual app
+55 00 00 00 00 => 0000 0100 0200 0300 3300 0004 9000
+55000000 00                # default expectation
+
+# Unknown instruction, matched by status word
+55 09 00 00 00 => 6D00
+55 09 00 00 00 => .*9000
+55 00 00 00 00 => .*90.. # still run when continuing
+`
+
+func parseTestScript(t *testing.T, script string) []ScriptStep {
+	steps, err := ParseScript(strings.NewReader(script))
+	require.NoError(t, err)
+	return steps
+}
+
+func TestParseScript(t *testing.T) {
+	steps := parseTestScript(t, testScript)
+	require.Len(t, steps, 5)
+
+	assert.Equal(t, 3, steps[0].Line)
+	assert.Equal(t, &Command{CLA: virtualCLA, INS: InsGetVersion, Data: []byte{}}, steps[0].Command)
+	assert.Equal(t, "0000010002000300330000049000", steps[0].Expectation)
+	assert.Equal(t, "", steps[1].Expectation)
+	assert.Equal(t, "6D00", steps[2].Expectation)
+	assert.True(t, steps[2].Expected.MatchString("6d00"))
+	assert.False(t, steps[2].Expected.MatchString("016d00"))
+}
+
+func TestParseScriptErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		script string
+		cause  error
+	}{
+		{"InvalidHex", "55 0z 00 00 00", nil},
+		{"TooShort", "55 00 00", ErrCommandTooShort},
+		{"LengthMismatch", "55 00 00 00 02 01", ErrCommandLengthMismatch},
+		{"EmptyExpectation", "55 00 00 00 00 =>", nil},
+		{"InvalidExpression", "55 00 00 00 00 => 90(", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseScript(strings.NewReader("# header\n" + tt.script))
+			assert.ErrorIs(t, err, ErrInvalidScript)
+			assert.ErrorContains(t, err, "line 2")
+			if tt.cause != nil {
+				assert.ErrorIs(t, err, tt.cause)
+			}
+		})
+	}
+}
+
+func TestRunScriptStopsOnMismatch(t *testing.T) {
+	report := RunScript(newVirtualApp(), parseTestScript(t, testScript), ScriptOptions{})
+
+	require.Len(t, report.Results, 4)
+	assert.True(t, report.Results[0].Passed())
+	assert.True(t, report.Results[1].Passed())
+	assert.True(t, report.Results[2].Passed())
+	assert.Equal(t, []byte{0x6d, 0x00}, report.Results[2].Response)
+
+	failed := report.Results[3]
+	assert.ErrorIs(t, failed.Err, ErrScriptMismatch)
+	sw, ok := StatusWord(failed.Err)
+	assert.True(t, ok)
+	assert.Equal(t, uint16(SwInsNotSupported), sw)
+
+	assert.Equal(t, 1, report.Failed())
+	assert.Equal(t, 1, report.Skipped)
+	assert.False(t, report.Passed())
+}
+
+func TestRunScriptContinueOnMismatch(t *testing.T) {
+	report := RunScript(newVirtualApp(), parseTestScript(t, testScript), ScriptOptions{ContinueOnMismatch: true})
+
+	require.Len(t, report.Results, 5)
+	assert.True(t, report.Results[4].Passed())
+	assert.Equal(t, 1, report.Failed())
+	assert.Equal(t, 0, report.Skipped)
+
+	var sb strings.Builder
+	require.NoError(t, report.Write(&sb))
+	lines := strings.Split(strings.TrimSpace(sb.String()), "\n")
+	require.Len(t, lines, 6)
+	assert.Equal(t, "PASS line 7: 5509000000 => 6d00", lines[2])
+	assert.True(t, strings.HasPrefix(lines[3], "FAIL line 8: 5509000000 => 6d00: unexpected reply, expected .*9000"), lines[3])
+	assert.Equal(t, "4 passed, 1 failed, 0 skipped", lines[5])
+}
+
+func TestRunScriptTransportError(t *testing.T) {
+	device := NewFaultyDevice(newVirtualApp(), FaultConfig{DisconnectAfter: 1})
+	report := RunScript(device, parseTestScript(t, testScript), ScriptOptions{})
+
+	require.Len(t, report.Results, 2)
+	failed := report.Results[1]
+	assert.Nil(t, failed.Response)
+	assert.ErrorIs(t, failed.Err, ErrDisconnected)
+	assert.False(t, errors.Is(failed.Err, ErrScriptMismatch))
+	assert.Equal(t, 3, report.Skipped)
+}
@@ -0,0 +1,125 @@
+/*******************************************************************************
+*   (c) Zondax AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+********************************************************************************/
+
+// Package slip44 provides a registry of the SLIP-0044 coin types used in BIP44 paths.
+// It embeds a curated subset of the registered coins, Register adds the others.
+package slip44
+
+import (
+	_ "embed"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+//go:embed registry.tsv
+var registryData string
+
+// Coin is a registered coin type.
+type Coin struct {
+	CoinType uint32
+	Symbol   string
+	Name     string
+}
+
+func (c Coin) String() string {
+	if c.Symbol == "" {
+		return c.Name
+	}
+	return fmt.Sprintf("%s (%s)", c.Name, c.Symbol)
+}
+
+var (
+	mu         sync.RWMutex
+	byCoinType = make(map[uint32]Coin)
+	bySymbol   = make(map[string]Coin)
+	coins      []Coin
+)
+
+func init() {
+	for i, line := range strings.Split(registryData, "\n") {
+		line = strings.TrimRight(line, "\r")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Split(line, "\t")
+		if len(fields) != 3 {
+			panic(fmt.Sprintf("slip44: malformed registry line %d: %q", i+1, line))
+		}
+
+		coinType, err := strconv.ParseUint(fields[0], 10, 31)
+		if err != nil {
+			panic(fmt.Sprintf("slip44: bad coin type on registry line %d: %v", i+1, err))
+		}
+
+		Register(Coin{CoinType: uint32(coinType), Symbol: fields[1], Name: fields[2]})
+	}
+}
+
+// Register adds or replaces a coin in the registry, e.g. for coin types not yet embedded.
+func Register(coin Coin) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if previous, ok := byCoinType[coin.CoinType]; ok {
+		delete(bySymbol, strings.ToUpper(previous.Symbol))
+		for i := range coins {
+			if coins[i].CoinType == coin.CoinType {
+				coins = append(coins[:i], coins[i+1:]...)
+				break
+			}
+		}
+	}
+
+	byCoinType[coin.CoinType] = coin
+	if coin.Symbol != "" {
+		bySymbol[strings.ToUpper(coin.Symbol)] = coin
+	}
+
+	index := sort.Search(len(coins), func(i int) bool { return coins[i].CoinType >= coin.CoinType })
+	coins = append(coins, Coin{})
+	copy(coins[index+1:], coins[index:])
+	coins[index] = coin
+}
+
+// Lookup returns the coin registered for coinType. The hardened bit, if present, is ignored.
+func Lookup(coinType uint32) (Coin, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	coin, ok := byCoinType[coinType&^0x80000000]
+	return coin, ok
+}
+
+// LookupSymbol returns the coin registered with symbol, ignoring case.
+func LookupSymbol(symbol string) (Coin, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	coin, ok := bySymbol[strings.ToUpper(symbol)]
+	return coin, ok
+}
+
+// All returns every registered coin ordered by coin type.
+func All() []Coin {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	return append([]Coin(nil), coins...)
+}
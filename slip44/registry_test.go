@@ -0,0 +1,125 @@
+/*******************************************************************************
+*   (c) Zondax AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+********************************************************************************/
+
+package slip44
+
+import (
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLookup(t *testing.T) {
+	coin, ok := Lookup(461)
+	require.True(t, ok)
+	assert.Equal(t, Coin{CoinType: 461, Symbol: "FIL", Name: "Filecoin"}, coin)
+	assert.Equal(t, "Filecoin (FIL)", coin.String())
+
+	coin, ok = Lookup(118 | 0x80000000)
+	require.True(t, ok)
+	assert.Equal(t, "ATOM", coin.Symbol)
+
+	coin, ok = Lookup(1)
+	require.True(t, ok)
+	assert.Equal(t, "Testnet (all coins)", coin.String())
+
+	_, ok = Lookup(0x7ffffff0)
+	assert.False(t, ok)
+}
+
+func TestLookupSymbol(t *testing.T) {
+	coin, ok := LookupSymbol("sol")
+	require.True(t, ok)
+	assert.Equal(t, uint32(501), coin.CoinType)
+
+	_, ok = LookupSymbol("")
+	assert.False(t, ok)
+}
+
+func TestAll(t *testing.T) {
+	coins := All()
+	require.NotEmpty(t, coins)
+	assert.True(t, sort.SliceIsSorted(coins, func(i, j int) bool { return coins[i].CoinType < coins[j].CoinType }))
+
+	// The result is a copy
+	coins[0].Name = "changed"
+	assert.NotEqual(t, "changed", All()[0].Name)
+}
+
+// restoreRegistry puts the registry back as it is now when the test ends
+func restoreRegistry(t *testing.T) {
+	mu.RLock()
+	savedCoinType := make(map[uint32]Coin, len(byCoinType))
+	for k, v := range byCoinType {
+		savedCoinType[k] = v
+	}
+	savedSymbol := make(map[string]Coin, len(bySymbol))
+	for k, v := range bySymbol {
+		savedSymbol[k] = v
+	}
+	savedCoins := append([]Coin(nil), coins...)
+	mu.RUnlock()
+
+	t.Cleanup(func() {
+		mu.Lock()
+		defer mu.Unlock()
+		byCoinType, bySymbol, coins = savedCoinType, savedSymbol, savedCoins
+	})
+}
+
+func TestRegister(t *testing.T) {
+	restoreRegistry(t)
+
+	Register(Coin{CoinType: 999999, Symbol: "TST", Name: "Test coin"})
+	Register(Coin{CoinType: 999999, Symbol: "TST2", Name: "Test coin 2"})
+
+	coin, ok := Lookup(999999)
+	require.True(t, ok)
+	assert.Equal(t, "Test coin 2", coin.Name)
+
+	_, ok = LookupSymbol("TST")
+	assert.False(t, ok)
+
+	count := 0
+	for _, c := range All() {
+		if c.CoinType == 999999 {
+			count++
+		}
+	}
+	assert.Equal(t, 1, count)
+}
+
+func TestRegisterConcurrent(t *testing.T) {
+	restoreRegistry(t)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			Register(Coin{CoinType: 999990 + uint32(i), Symbol: "TST", Name: "Test coin"})
+			_, _ = LookupSymbol("TST")
+			_ = All()
+		}(i)
+	}
+	wg.Wait()
+
+	_, ok := Lookup(999990)
+	assert.True(t, ok)
+}
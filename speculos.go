@@ -0,0 +1,378 @@
+/*******************************************************************************
+*   (c) Zondax AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+********************************************************************************/
+
+package ledger_go
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	defaultSpeculosURL = "http://127.0.0.1:5000"
+
+	// SpeculosURLEnv overrides the default Speculos API address, as "http://host:port"
+	SpeculosURLEnv = "LEDGER_SPECULOS_URL"
+
+	defaultSpeculosProbeTimeout = time.Second
+	speculosDeviceName          = "Speculos device"
+
+	speculosPressAndRelease = "press-and-release"
+)
+
+// SpeculosButton is a button of a Speculos device, as named in the API paths.
+type SpeculosButton string
+
+const (
+	SpeculosLeft  SpeculosButton = "left"
+	SpeculosRight SpeculosButton = "right"
+	SpeculosBoth  SpeculosButton = "both"
+)
+
+// SpeculosEvent is a text event of the Speculos display.
+type SpeculosEvent struct {
+	Text  string `json:"text"`
+	X     int    `json:"x"`
+	Y     int    `json:"y"`
+	W     int    `json:"w,omitempty"`
+	H     int    `json:"h,omitempty"`
+	Clear bool   `json:"clear,omitempty"`
+}
+
+// LedgerAdminSpeculos gives access to a single Speculos emulator through its REST API.
+type LedgerAdminSpeculos struct {
+	baseURL      string
+	client       *http.Client
+	probeTimeout time.Duration
+}
+
+// SpeculosDevice is a LedgerDevice exchanging over the Speculos /apdu endpoint.
+// It also drives the emulator: buttons, touch screen, screenshots and display events.
+type SpeculosDevice struct {
+	baseURL string
+	client  *http.Client
+	closed  atomic.Bool
+}
+
+// SpeculosOption configures a LedgerAdminSpeculos.
+type SpeculosOption func(admin *LedgerAdminSpeculos)
+
+// WithSpeculosURL sets the API address, as "http://host:port".
+func WithSpeculosURL(baseURL string) SpeculosOption {
+	return func(admin *LedgerAdminSpeculos) {
+		admin.baseURL = strings.TrimSuffix(baseURL, "/")
+	}
+}
+
+// WithSpeculosHTTPClient sets the http client used for API calls.
+func WithSpeculosHTTPClient(client *http.Client) SpeculosOption {
+	return func(admin *LedgerAdminSpeculos) {
+		admin.client = client
+	}
+}
+
+// WithSpeculosProbeTimeout bounds the reachability check of ListDevices and CountDevices.
+func WithSpeculosProbeTimeout(timeout time.Duration) SpeculosOption {
+	return func(admin *LedgerAdminSpeculos) {
+		admin.probeTimeout = timeout
+	}
+}
+
+// NewLedgerAdminSpeculos returns an admin for a single emulator. Options take precedence over
+// the LEDGER_SPECULOS_URL environment variable, which takes precedence over http://127.0.0.1:5000.
+func NewLedgerAdminSpeculos(options ...SpeculosOption) *LedgerAdminSpeculos {
+	admin := &LedgerAdminSpeculos{
+		baseURL:      defaultSpeculosURL,
+		client:       http.DefaultClient,
+		probeTimeout: defaultSpeculosProbeTimeout,
+	}
+
+	if baseURL := os.Getenv(SpeculosURLEnv); baseURL != "" {
+		WithSpeculosURL(baseURL)(admin)
+	}
+
+	for _, option := range options {
+		option(admin)
+	}
+
+	return admin
+}
+
+// URL returns the API address.
+func (admin *LedgerAdminSpeculos) URL() string {
+	return admin.baseURL
+}
+
+// reachable reports whether the emulator answers API calls.
+func (admin *LedgerAdminSpeculos) reachable() bool {
+	ctx, cancel := context.WithTimeout(context.Background(), admin.probeTimeout)
+	defer cancel()
+
+	device := admin.device()
+	_, err := device.Events(ctx, true)
+	return err == nil
+}
+
+// ListDevices returns the emulator when it is reachable.
+func (admin *LedgerAdminSpeculos) ListDevices() ([]string, error) {
+	if !admin.reachable() {
+		return []string{}, nil
+	}
+	return []string{fmt.Sprintf("%s (%s)", speculosDeviceName, admin.baseURL)}, nil
+}
+
+// CountDevices returns 1 when the emulator is reachable, 0 otherwise.
+func (admin *LedgerAdminSpeculos) CountDevices() int {
+	if !admin.reachable() {
+		return 0
+	}
+	return 1
+}
+
+// Connect returns a device for the emulator. Connection failures show on the first call.
+func (admin *LedgerAdminSpeculos) Connect(deviceIndex int) (LedgerDevice, error) {
+	if deviceIndex != 0 {
+		return nil, fmt.Errorf("speculos %w (idx %d): only one emulated device", ErrDeviceNotFound, deviceIndex)
+	}
+	return admin.device(), nil
+}
+
+func (admin *LedgerAdminSpeculos) device() *SpeculosDevice {
+	return &SpeculosDevice{baseURL: admin.baseURL, client: admin.client}
+}
+
+// do calls the API and returns the response body. Failures to reach the emulator are
+// reported as ErrEmulatorUnreachable.
+func (d *SpeculosDevice) do(ctx context.Context, method string, path string, request interface{}) ([]byte, error) {
+	if d.closed.Load() {
+		return nil, ErrDeviceClosed
+	}
+
+	response, err := d.send(ctx, method, path, request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrEmulatorUnreachable, err)
+	}
+	return body, nil
+}
+
+// send starts an API call, failing on error statuses.
+func (d *SpeculosDevice) send(ctx context.Context, method string, path string, request interface{}) (*http.Response, error) {
+	var body io.Reader
+	if request != nil {
+		encoded, err := json.Marshal(request)
+		if err != nil {
+			return nil, err
+		}
+		body = bytes.NewReader(encoded)
+	}
+
+	httpRequest, err := http.NewRequestWithContext(ctx, method, d.baseURL+path, body)
+	if err != nil {
+		return nil, err
+	}
+	if request != nil {
+		httpRequest.Header.Set("Content-Type", "application/json")
+	}
+
+	response, err := d.client.Do(httpRequest)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrEmulatorUnreachable, err)
+	}
+
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		message, _ := io.ReadAll(io.LimitReader(response.Body, 512))
+		_ = response.Body.Close()
+		return nil, fmt.Errorf("speculos %s %s: %s %s", method, path, response.Status, bytes.TrimSpace(message))
+	}
+	return response, nil
+}
+
+type speculosData struct {
+	Data string `json:"data"`
+}
+
+type speculosAction struct {
+	Action string `json:"action"`
+}
+
+// speculosTouch always sends both coordinates, 0 being a valid one
+type speculosTouch struct {
+	Action string `json:"action"`
+	X      int    `json:"x"`
+	Y      int    `json:"y"`
+}
+
+type speculosEvents struct {
+	Events []SpeculosEvent `json:"events"`
+}
+
+func (d *SpeculosDevice) Exchange(command []byte) ([]byte, error) {
+	if err := ValidateCommand(command); err != nil {
+		return nil, err
+	}
+
+	body, err := d.do(context.Background(), http.MethodPost, "/apdu", speculosData{Data: hex.EncodeToString(command)})
+	if err != nil {
+		return nil, err
+	}
+
+	var reply speculosData
+	if err := json.Unmarshal(body, &reply); err != nil {
+		return nil, fmt.Errorf("invalid speculos reply: %w", err)
+	}
+	response, err := hex.DecodeString(reply.Data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid speculos reply: %w", err)
+	}
+
+	return ParseResponse(response)
+}
+
+// PressButton presses and releases a button.
+func (d *SpeculosDevice) PressButton(button SpeculosButton) error {
+	_, err := d.do(context.Background(), http.MethodPost, "/button/"+string(button), speculosAction{Action: speculosPressAndRelease})
+	return err
+}
+
+func (d *SpeculosDevice) PressLeft() error {
+	return d.PressButton(SpeculosLeft)
+}
+
+func (d *SpeculosDevice) PressRight() error {
+	return d.PressButton(SpeculosRight)
+}
+
+func (d *SpeculosDevice) PressBoth() error {
+	return d.PressButton(SpeculosBoth)
+}
+
+// Touch taps the touch screen of Stax and Flex devices at x, y.
+func (d *SpeculosDevice) Touch(x int, y int) error {
+	_, err := d.do(context.Background(), http.MethodPost, "/finger", speculosTouch{Action: speculosPressAndRelease, X: x, Y: y})
+	return err
+}
+
+// Screenshot returns a PNG image of the screen.
+func (d *SpeculosDevice) Screenshot() ([]byte, error) {
+	return d.do(context.Background(), http.MethodGet, "/screenshot", nil)
+}
+
+// Events returns the display events since the last ResetEvents, or only the ones
+// of the current screen.
+func (d *SpeculosDevice) Events(ctx context.Context, currentScreenOnly bool) ([]SpeculosEvent, error) {
+	path := "/events"
+	if currentScreenOnly {
+		path += "?currentscreenonly=true"
+	}
+
+	body, err := d.do(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var events speculosEvents
+	if err := json.Unmarshal(body, &events); err != nil {
+		return nil, fmt.Errorf("invalid speculos events: %w", err)
+	}
+	return events.Events, nil
+}
+
+// ResetEvents clears the display events recorded so far.
+func (d *SpeculosDevice) ResetEvents() error {
+	_, err := d.do(context.Background(), http.MethodDelete, "/events", nil)
+	return err
+}
+
+// StreamEvents streams display events until ctx is done or the emulator closes the stream.
+// Past events are sent first. The channel is closed when the stream ends.
+func (d *SpeculosDevice) StreamEvents(ctx context.Context) (<-chan SpeculosEvent, error) {
+	if d.closed.Load() {
+		return nil, ErrDeviceClosed
+	}
+
+	response, err := d.send(ctx, http.MethodGet, "/events?stream=true", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	events := make(chan SpeculosEvent)
+	go func() {
+		defer close(events)
+		defer response.Body.Close()
+
+		scanner := bufio.NewScanner(response.Body)
+		for scanner.Scan() {
+			// Events come one per line, possibly as server-sent events
+			line := strings.TrimSpace(strings.TrimPrefix(scanner.Text(), "data:"))
+			if line == "" {
+				continue
+			}
+
+			var event SpeculosEvent
+			if json.Unmarshal([]byte(line), &event) != nil {
+				continue
+			}
+
+			select {
+			case events <- event:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return events, nil
+}
+
+// WaitForText waits for a display event containing text, past events included.
+// ErrTextNotFound is returned when it does not show within timeout.
+func (d *SpeculosDevice) WaitForText(text string, timeout time.Duration) (SpeculosEvent, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	events, err := d.StreamEvents(ctx)
+	if err != nil {
+		return SpeculosEvent{}, err
+	}
+
+	for event := range events {
+		if strings.Contains(event.Text, text) {
+			return event, nil
+		}
+	}
+	return SpeculosEvent{}, fmt.Errorf("%w: %q", ErrTextNotFound, text)
+}
+
+// Close makes further calls fail with ErrDeviceClosed. The emulator keeps running.
+func (d *SpeculosDevice) Close() error {
+	d.closed.Store(true)
+	return nil
+}
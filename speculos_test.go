@@ -0,0 +1,356 @@
+/*******************************************************************************
+*   (c) Zondax AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+********************************************************************************/
+
+package ledger_go
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSpeculos serves the Speculos REST API for an emulated app, logging the screens as events
+type fakeSpeculos struct {
+	emulator *ZemuEmulator
+
+	mu     sync.Mutex
+	events []SpeculosEvent
+	// resets counts the deletions of events, for streams to start over
+	resets  int
+	touches [][2]int
+	logged  chan struct{}
+}
+
+func newFakeSpeculos(t *testing.T, emulator *ZemuEmulator) (*fakeSpeculos, *httptest.Server) {
+	fake := &fakeSpeculos{emulator: emulator, logged: make(chan struct{})}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/apdu", fake.apdu)
+	mux.HandleFunc("/button/", fake.button)
+	mux.HandleFunc("/finger", fake.finger)
+	mux.HandleFunc("/screenshot", fake.screenshot)
+	mux.HandleFunc("/events", fake.serveEvents)
+
+	server := httptest.NewServer(mux)
+	stop := make(chan struct{})
+	go fake.watch(stop)
+	t.Cleanup(func() {
+		close(stop)
+		server.Close()
+	})
+	return fake, server
+}
+
+// watch logs the lines of every screen shown by the emulator
+func (f *fakeSpeculos) watch(stop <-chan struct{}) {
+	for {
+		f.emulator.mu.Lock()
+		screen := f.emulator.screenLocked()
+		changed := f.emulator.changed
+		f.emulator.mu.Unlock()
+
+		f.mu.Lock()
+		for i, line := range screen {
+			f.events = append(f.events, SpeculosEvent{Text: line, X: 10, Y: 10 + 16*i})
+		}
+		close(f.logged)
+		f.logged = make(chan struct{})
+		f.mu.Unlock()
+
+		select {
+		case <-changed:
+		case <-stop:
+			return
+		}
+	}
+}
+
+func (f *fakeSpeculos) apdu(w http.ResponseWriter, r *http.Request) {
+	var request speculosData
+	if r.Method != http.MethodPost || json.NewDecoder(r.Body).Decode(&request) != nil {
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return
+	}
+	command, err := hex.DecodeString(request.Data)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	_ = json.NewEncoder(w).Encode(speculosData{Data: hex.EncodeToString(f.emulator.device.Process(command))})
+}
+
+func (f *fakeSpeculos) button(w http.ResponseWriter, r *http.Request) {
+	buttons := map[string]ZemuButton{
+		"/button/left":  ZemuButton_BUTTON_LEFT,
+		"/button/right": ZemuButton_BUTTON_RIGHT,
+		"/button/both":  ZemuButton_BUTTON_BOTH,
+	}
+	button, ok := buttons[r.URL.Path]
+
+	var action speculosAction
+	if !ok || json.NewDecoder(r.Body).Decode(&action) != nil || action.Action != speculosPressAndRelease {
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return
+	}
+	_, _ = f.emulator.press(button)
+	_, _ = w.Write([]byte("{}"))
+}
+
+func (f *fakeSpeculos) finger(w http.ResponseWriter, r *http.Request) {
+	// Like Speculos, both coordinates are required
+	var touch struct {
+		Action string `json:"action"`
+		X      *int   `json:"x"`
+		Y      *int   `json:"y"`
+	}
+	if json.NewDecoder(r.Body).Decode(&touch) != nil || touch.X == nil || touch.Y == nil {
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return
+	}
+	f.mu.Lock()
+	f.touches = append(f.touches, [2]int{*touch.X, *touch.Y})
+	f.mu.Unlock()
+	_, _ = w.Write([]byte("{}"))
+}
+
+func (f *fakeSpeculos) recordedTouches() [][2]int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([][2]int(nil), f.touches...)
+}
+
+func (f *fakeSpeculos) screenshot(w http.ResponseWriter, r *http.Request) {
+	snapshot, _ := f.emulator.Snapshot(r.Context(), &SnapshotRequest{})
+	w.Header().Set("Content-Type", "image/png")
+	_, _ = w.Write(snapshot.Image)
+}
+
+func (f *fakeSpeculos) serveEvents(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.Method == http.MethodDelete:
+		f.mu.Lock()
+		f.events = nil
+		f.resets++
+		f.mu.Unlock()
+		_, _ = w.Write([]byte("{}"))
+
+	case r.URL.Query().Get("stream") == "true":
+		f.stream(w, r)
+
+	case r.URL.Query().Get("currentscreenonly") == "true":
+		var events []SpeculosEvent
+		for i, line := range f.emulator.Screen() {
+			events = append(events, SpeculosEvent{Text: line, X: 10, Y: 10 + 16*i})
+		}
+		_ = json.NewEncoder(w).Encode(speculosEvents{Events: events})
+
+	default:
+		f.mu.Lock()
+		events := speculosEvents{Events: append([]SpeculosEvent{}, f.events...)}
+		f.mu.Unlock()
+		_ = json.NewEncoder(w).Encode(events)
+	}
+}
+
+// stream sends the logged events, then the new ones as they come, as server-sent events
+func (f *fakeSpeculos) stream(w http.ResponseWriter, r *http.Request) {
+	sent, resets := 0, 0
+	for {
+		f.mu.Lock()
+		if resets != f.resets {
+			sent, resets = 0, f.resets
+		}
+		pending := f.events[sent:]
+		logged := f.logged
+		f.mu.Unlock()
+
+		for _, event := range pending {
+			encoded, _ := json.Marshal(event)
+			_, _ = fmt.Fprintf(w, "data: %s\n\n", encoded)
+		}
+		sent += len(pending)
+		w.(http.Flusher).Flush()
+
+		select {
+		case <-logged:
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+func newSpeculosDevice(t *testing.T) (*SpeculosDevice, *fakeSpeculos) {
+	fake, server := newFakeSpeculos(t, newEmulatedApp())
+	device, err := NewLedgerAdminSpeculos(WithSpeculosURL(server.URL + "/")).Connect(0)
+	require.NoError(t, err)
+	return device.(*SpeculosDevice), fake
+}
+
+func TestSpeculosURL(t *testing.T) {
+	t.Setenv(SpeculosURLEnv, "")
+	assert.Equal(t, "http://127.0.0.1:5000", NewLedgerAdminSpeculos().URL())
+
+	t.Setenv(SpeculosURLEnv, "http://speculos:5001")
+	assert.Equal(t, "http://speculos:5001", NewLedgerAdminSpeculos().URL())
+	assert.Equal(t, "http://other:5002", NewLedgerAdminSpeculos(WithSpeculosURL("http://other:5002/")).URL())
+}
+
+func TestSpeculosAdmin(t *testing.T) {
+	_, server := newFakeSpeculos(t, newEmulatedApp())
+
+	admin := NewLedgerAdminSpeculos(WithSpeculosURL(server.URL))
+	assert.Equal(t, 1, admin.CountDevices())
+	devices, err := admin.ListDevices()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Speculos device (" + server.URL + ")"}, devices)
+
+	_, err = admin.Connect(1)
+	assert.ErrorIs(t, err, ErrDeviceNotFound)
+
+	server.Close()
+	assert.Equal(t, 0, admin.CountDevices())
+
+	device, err := admin.Connect(0)
+	require.NoError(t, err)
+	_, err = device.Exchange([]byte{virtualCLA, InsGetVersion, 0x00, 0x00, 0x00})
+	assert.ErrorIs(t, err, ErrEmulatorUnreachable)
+}
+
+func TestSpeculosExchange(t *testing.T) {
+	device, _ := newSpeculosDevice(t)
+
+	version, err := NewAppClient(device, AppConfig{CLA: virtualCLA}).GetVersion()
+	require.NoError(t, err)
+	assert.Equal(t, "1.2.3", version.String())
+
+	_, err = device.Exchange([]byte{virtualCLA, 0x09, 0x00, 0x00, 0x00})
+	sw, ok := StatusWord(err)
+	assert.True(t, ok)
+	assert.Equal(t, uint16(SwInsNotSupported), sw)
+
+	_, err = device.Exchange([]byte{virtualCLA, 0x09, 0x00})
+	assert.ErrorIs(t, err, ErrCommandTooShort)
+
+	require.NoError(t, device.Close())
+	_, err = device.Exchange([]byte{virtualCLA, InsGetVersion, 0x00, 0x00, 0x00})
+	assert.ErrorIs(t, err, ErrDeviceClosed)
+	assert.ErrorIs(t, device.PressLeft(), ErrDeviceClosed)
+}
+
+func TestSpeculosApproveWithButtons(t *testing.T) {
+	device, _ := newSpeculosDevice(t)
+
+	for _, approve := range []bool{true, false} {
+		done := make(chan error, 1)
+		go func() {
+			_, err := device.Exchange([]byte{virtualCLA, insConfirm, 0x00, 0x00, 0x00})
+			done <- err
+		}()
+
+		_, err := device.WaitForText("Review", 2*time.Second)
+		require.NoError(t, err)
+
+		// Go through the review to the Approve screen, one more press reaches Reject
+		for i := 0; i < 3; i++ {
+			require.NoError(t, device.PressRight())
+		}
+		if !approve {
+			require.NoError(t, device.PressRight())
+		}
+		require.NoError(t, device.PressBoth())
+
+		err = <-done
+		if approve {
+			assert.NoError(t, err)
+		} else {
+			assert.EqualError(t, err, ErrorMessage(SwCommandNotAllowed))
+		}
+
+		_, err = device.WaitForText("is ready", 2*time.Second)
+		require.NoError(t, err)
+		require.NoError(t, device.ResetEvents())
+	}
+}
+
+func TestSpeculosEvents(t *testing.T) {
+	device, _ := newSpeculosDevice(t)
+	ctx := context.Background()
+
+	events, err := device.Events(ctx, true)
+	require.NoError(t, err)
+	assert.Equal(t, []SpeculosEvent{{Text: "Application", X: 10, Y: 10}, {Text: "is ready", X: 10, Y: 26}}, events)
+
+	_, err = device.WaitForText("Approve", 50*time.Millisecond)
+	assert.ErrorIs(t, err, ErrTextNotFound)
+
+	require.NoError(t, device.ResetEvents())
+	events, err = device.Events(ctx, false)
+	require.NoError(t, err)
+	assert.Empty(t, events)
+}
+
+func TestSpeculosStreamAcrossReset(t *testing.T) {
+	device, _ := newSpeculosDevice(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	events, err := device.StreamEvents(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "Application", (<-events).Text)
+	assert.Equal(t, "is ready", (<-events).Text)
+
+	// The open stream starts over with the events logged after the reset
+	require.NoError(t, device.ResetEvents())
+	done := make(chan error, 1)
+	go func() {
+		_, err := device.Exchange([]byte{virtualCLA, insConfirm, 0x00, 0x00, 0x00})
+		done <- err
+	}()
+	assert.Equal(t, "Review", (<-events).Text)
+
+	for i := 0; i < 3; i++ {
+		require.NoError(t, device.PressRight())
+	}
+	require.NoError(t, device.PressBoth())
+	assert.NoError(t, <-done)
+}
+
+func TestSpeculosTouchAndScreenshot(t *testing.T) {
+	device, fake := newSpeculosDevice(t)
+
+	require.NoError(t, device.Touch(200, 400))
+	require.NoError(t, device.Touch(0, 0))
+	require.NoError(t, device.Touch(0, 120))
+	assert.Equal(t, [][2]int{{200, 400}, {0, 0}, {0, 120}}, fake.recordedTouches())
+
+	image, err := device.Screenshot()
+	require.NoError(t, err)
+	_, err = png.DecodeConfig(bytes.NewReader(image))
+	assert.NoError(t, err)
+
+	assert.ErrorContains(t, device.PressButton("middle"), "400 Bad Request")
+}
@@ -0,0 +1,369 @@
+/*******************************************************************************
+*   (c) Zondax AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+********************************************************************************/
+
+package ledger_go
+
+// Transcript format
+//
+// Sessions are stored as text, compatible with the APDU transcripts used by Ledger Live:
+//
+//	=> e001000000
+//	<= 311000040853706563756c6f73000b53706563756c6f734d43559000
+//
+// "=>" lines hold a command and "<=" lines the raw reply, status word included.
+// Lines starting with "#" are comments, with one exception: a "# elapsed <duration>"
+// line following a reply records how long the exchange took. An exchange that failed
+// without a status word (e.g. a disconnected device) is recorded as "!! <error text>"
+// instead of a reply. Blank lines are ignored.
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	transcriptCommandPrefix = "=>"
+	transcriptReplyPrefix   = "<="
+	transcriptErrorPrefix   = "!!"
+	transcriptElapsedPrefix = "# elapsed"
+)
+
+const (
+	ErrMsgReplayMismatch  = "replay mismatch"
+	ErrMsgReplayExhausted = "replay exhausted"
+	ErrMsgInvalidRecord   = "invalid transcript"
+)
+
+var (
+	ErrReplayMismatch  = errors.New(ErrMsgReplayMismatch)
+	ErrReplayExhausted = errors.New(ErrMsgReplayExhausted)
+	ErrInvalidRecord   = errors.New(ErrMsgInvalidRecord)
+)
+
+// TranscriptEntry is a recorded exchange.
+type TranscriptEntry struct {
+	Command []byte
+	// Response is the raw reply, including the status word
+	Response []byte
+	// Err is set instead of Response when the exchange failed without a status word
+	Err     string
+	Elapsed time.Duration
+}
+
+// StatusWord returns the status word of the recorded reply.
+func (e TranscriptEntry) StatusWord() (uint16, bool) {
+	if len(e.Response) < 2 {
+		return 0, false
+	}
+	return codec.Uint16(e.Response[len(e.Response)-2:]), true
+}
+
+// WriteTranscriptEntry writes entry in the transcript format, including the timing when withTiming is set.
+func WriteTranscriptEntry(w io.Writer, entry TranscriptEntry, withTiming bool) error {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s %x\n", transcriptCommandPrefix, entry.Command)
+	if entry.Err != "" {
+		fmt.Fprintf(&sb, "%s %s\n", transcriptErrorPrefix, strings.ReplaceAll(entry.Err, "\n", " "))
+	} else {
+		fmt.Fprintf(&sb, "%s %x\n", transcriptReplyPrefix, entry.Response)
+	}
+	if withTiming {
+		fmt.Fprintf(&sb, "%s %s\n", transcriptElapsedPrefix, entry.Elapsed)
+	}
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// ParseTranscript reads the exchanges stored in a transcript.
+func ParseTranscript(r io.Reader) ([]TranscriptEntry, error) {
+	var entries []TranscriptEntry
+	var pending *TranscriptEntry
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+
+		switch {
+		case line == "":
+			continue
+
+		case strings.HasPrefix(line, transcriptElapsedPrefix):
+			if len(entries) == 0 || pending != nil {
+				continue
+			}
+			elapsed, err := time.ParseDuration(strings.TrimSpace(strings.TrimPrefix(line, transcriptElapsedPrefix)))
+			if err != nil {
+				return nil, fmt.Errorf("%w: line %d: %w", ErrInvalidRecord, lineNumber, err)
+			}
+			entries[len(entries)-1].Elapsed = elapsed
+
+		case strings.HasPrefix(line, "#"):
+			continue
+
+		case strings.HasPrefix(line, transcriptCommandPrefix):
+			if pending != nil {
+				return nil, fmt.Errorf("%w: line %d: command without reply", ErrInvalidRecord, lineNumber)
+			}
+			command, err := parseTranscriptHex(strings.TrimPrefix(line, transcriptCommandPrefix))
+			if err != nil {
+				return nil, fmt.Errorf("%w: line %d: %w", ErrInvalidRecord, lineNumber, err)
+			}
+			pending = &TranscriptEntry{Command: command}
+
+		case strings.HasPrefix(line, transcriptReplyPrefix), strings.HasPrefix(line, transcriptErrorPrefix):
+			if pending == nil {
+				return nil, fmt.Errorf("%w: line %d: reply without command", ErrInvalidRecord, lineNumber)
+			}
+			if strings.HasPrefix(line, transcriptErrorPrefix) {
+				pending.Err = strings.TrimSpace(strings.TrimPrefix(line, transcriptErrorPrefix))
+			} else {
+				response, err := parseTranscriptHex(strings.TrimPrefix(line, transcriptReplyPrefix))
+				if err != nil {
+					return nil, fmt.Errorf("%w: line %d: %w", ErrInvalidRecord, lineNumber, err)
+				}
+				pending.Response = response
+			}
+			entries = append(entries, *pending)
+			pending = nil
+
+		default:
+			return nil, fmt.Errorf("%w: line %d: unexpected %q", ErrInvalidRecord, lineNumber, line)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if pending != nil {
+		return nil, fmt.Errorf("%w: last command has no reply", ErrInvalidRecord)
+	}
+
+	return entries, nil
+}
+
+func parseTranscriptHex(text string) ([]byte, error) {
+	return hex.DecodeString(strings.ReplaceAll(strings.TrimSpace(text), " ", ""))
+}
+
+// RecordingDevice forwards exchanges to a device and writes each of them to a transcript.
+type RecordingDevice struct {
+	mu     sync.Mutex
+	device LedgerDevice
+	w      io.Writer
+	closer io.Closer
+
+	// Timing adds the duration of each exchange to the transcript
+	Timing bool
+}
+
+// NewRecordingDevice records the exchanges with device to w, with timing.
+func NewRecordingDevice(device LedgerDevice, w io.Writer) *RecordingDevice {
+	return &RecordingDevice{device: device, w: w, Timing: true}
+}
+
+// RecordToFile records the exchanges with device to the file at path, which is closed with the device.
+func RecordToFile(device LedgerDevice, path string) (*RecordingDevice, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+
+	recorder := NewRecordingDevice(device, file)
+	recorder.closer = file
+	return recorder, nil
+}
+
+func (r *RecordingDevice) Exchange(command []byte) ([]byte, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	start := time.Now()
+	response, err := r.device.Exchange(command)
+	entry := TranscriptEntry{
+		Command: append([]byte{}, command...),
+		Elapsed: time.Since(start),
+	}
+
+	if raw, ok := rawResponse(response, err); ok {
+		entry.Response = raw
+	} else {
+		entry.Err = err.Error()
+	}
+
+	if writeErr := WriteTranscriptEntry(r.w, entry, r.Timing); writeErr != nil {
+		return response, fmt.Errorf("could not record exchange: %w", writeErr)
+	}
+
+	return response, err
+}
+
+// rawResponse rebuilds the raw reply that a transport stripped from its status word.
+// It fails when the exchange did not complete with a status word.
+func rawResponse(response []byte, err error) ([]byte, bool) {
+	sw := uint16(SwOK)
+	if err != nil {
+		code, ok := StatusWord(err)
+		if !ok {
+			return nil, false
+		}
+		sw = code
+	}
+	return statusReply(response, sw), true
+}
+
+func (r *RecordingDevice) Close() error {
+	err := r.device.Close()
+	if r.closer != nil {
+		if closeErr := r.closer.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	return err
+}
+
+// ReplayMode selects how a ReplayDevice matches commands to recorded exchanges.
+type ReplayMode int
+
+const (
+	// ReplayOrdered requires commands to arrive in the recorded order
+	ReplayOrdered ReplayMode = iota
+	// ReplayKeyed answers each command with the replies recorded for it, in order, repeating the last one
+	ReplayKeyed
+)
+
+// ReplayDevice answers commands with recorded exchanges.
+type ReplayDevice struct {
+	mu      sync.Mutex
+	entries []TranscriptEntry
+	mode    ReplayMode
+	next    int
+	keyed   map[string][]int
+	served  map[string]int
+	closed  bool
+
+	// SimulateTiming delays each reply by the recorded duration
+	SimulateTiming bool
+}
+
+func NewReplayDevice(entries []TranscriptEntry, mode ReplayMode) *ReplayDevice {
+	device := &ReplayDevice{
+		entries: entries,
+		mode:    mode,
+		keyed:   make(map[string][]int),
+		served:  make(map[string]int),
+	}
+	for i, entry := range entries {
+		key := hex.EncodeToString(entry.Command)
+		device.keyed[key] = append(device.keyed[key], i)
+	}
+	return device
+}
+
+// LoadReplayDevice reads the transcript at path.
+func LoadReplayDevice(path string, mode ReplayMode) (*ReplayDevice, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	entries, err := ParseTranscript(file)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return NewReplayDevice(entries, mode), nil
+}
+
+func (d *ReplayDevice) Exchange(command []byte) ([]byte, error) {
+	if err := ValidateCommand(command); err != nil {
+		return nil, err
+	}
+
+	entry, err := d.match(command)
+	if err != nil {
+		return nil, err
+	}
+
+	if d.SimulateTiming {
+		time.Sleep(entry.Elapsed)
+	}
+
+	if entry.Err != "" {
+		return nil, errors.New(entry.Err)
+	}
+	return ParseResponse(entry.Response)
+}
+
+func (d *ReplayDevice) match(command []byte) (TranscriptEntry, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.closed {
+		return TranscriptEntry{}, ErrDeviceClosed
+	}
+
+	if d.mode == ReplayKeyed {
+		key := hex.EncodeToString(command)
+		indexes, ok := d.keyed[key]
+		if !ok {
+			return TranscriptEntry{}, fmt.Errorf("%w: command %s was not recorded", ErrReplayMismatch, key)
+		}
+		served := d.served[key]
+		d.served[key]++
+		if served >= len(indexes) {
+			served = len(indexes) - 1
+		}
+		return d.entries[indexes[served]], nil
+	}
+
+	if d.next >= len(d.entries) {
+		return TranscriptEntry{}, fmt.Errorf("%w: unexpected command %x", ErrReplayExhausted, command)
+	}
+
+	entry := d.entries[d.next]
+	if !bytes.Equal(entry.Command, command) {
+		return TranscriptEntry{}, fmt.Errorf("%w: exchange %d expected %x, got %x", ErrReplayMismatch, d.next, entry.Command, command)
+	}
+	d.next++
+	return entry, nil
+}
+
+// Remaining returns the number of recorded exchanges not replayed yet in ordered mode.
+func (d *ReplayDevice) Remaining() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return len(d.entries) - d.next
+}
+
+func (d *ReplayDevice) Close() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.closed = true
+	return nil
+}
@@ -0,0 +1,177 @@
+/*******************************************************************************
+*   (c) Zondax AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+********************************************************************************/
+
+package ledger_go
+
+import (
+	"bytes"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const ledgerLiveTranscript = `
+# Captured with Ledger Live
+=> b001000000
+<= 0105424f4c4f5305322e312e30010a9000
+=> e0d8000006436f736d6f73
+<= 6807
+`
+
+func TestParseTranscript(t *testing.T) {
+	entries, err := ParseTranscript(strings.NewReader(ledgerLiveTranscript))
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+
+	assert.Equal(t, []byte{0xb0, 0x01, 0x00, 0x00, 0x00}, entries[0].Command)
+	sw, ok := entries[1].StatusWord()
+	assert.True(t, ok)
+	assert.Equal(t, uint16(SwAppNotInstalled), sw)
+}
+
+func TestParseTranscriptInvalid(t *testing.T) {
+	for _, text := range []string{
+		"=> e001000000",
+		"<= 9000",
+		"=> e001000000\n=> e001000000\n<= 9000",
+		"=> zz\n<= 9000",
+		"hello",
+		"=> e001000000\n<= 9000\n# elapsed soon",
+	} {
+		_, err := ParseTranscript(strings.NewReader(text))
+		assert.ErrorIs(t, err, ErrInvalidRecord, "transcript %q", text)
+	}
+}
+
+func TestRecordingDevice(t *testing.T) {
+	disconnected := errors.New("device disconnected")
+	device := exchangeFunc(func(command []byte) ([]byte, error) {
+		switch command[1] {
+		case 0x01:
+			return []byte{0xaa, 0xbb}, nil
+		case 0x02:
+			return []byte{}, &APDUError{Code: SwUserRefused}
+		default:
+			return nil, disconnected
+		}
+	})
+
+	var buffer bytes.Buffer
+	recorder := NewRecordingDevice(device, &buffer)
+
+	response, err := recorder.Exchange([]byte{0xe0, 0x01, 0x00, 0x00, 0x00})
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0xaa, 0xbb}, response)
+
+	_, err = recorder.Exchange([]byte{0xe0, 0x02, 0x00, 0x00, 0x00})
+	assert.EqualError(t, err, ErrorMessage(SwUserRefused))
+
+	_, err = recorder.Exchange([]byte{0xe0, 0x03, 0x00, 0x00, 0x00})
+	assert.ErrorIs(t, err, disconnected)
+
+	entries, err := ParseTranscript(&buffer)
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+	assert.Equal(t, []byte{0xaa, 0xbb, 0x90, 0x00}, entries[0].Response)
+	assert.Equal(t, []byte{0x55, 0x01}, entries[1].Response)
+	assert.Equal(t, "device disconnected", entries[2].Err)
+}
+
+func TestRecordThenReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session.apdu")
+
+	recorder, err := RecordToFile(replyWith(t, "e001000000", 0x6E00), path)
+	require.NoError(t, err)
+	_, err = recorder.Exchange([]byte{0xe0, 0x01, 0x00, 0x00, 0x00})
+	assert.Error(t, err)
+	require.NoError(t, recorder.Close())
+
+	replay, err := LoadReplayDevice(path, ReplayOrdered)
+	require.NoError(t, err)
+	_, err = replay.Exchange([]byte{0xe0, 0x01, 0x00, 0x00, 0x00})
+	sw, ok := StatusWord(err)
+	assert.True(t, ok)
+	assert.Equal(t, uint16(0x6E00), sw)
+	assert.Equal(t, 0, replay.Remaining())
+}
+
+func TestReplayOrdered(t *testing.T) {
+	entries, err := ParseTranscript(strings.NewReader(ledgerLiveTranscript))
+	require.NoError(t, err)
+	replay := NewReplayDevice(entries, ReplayOrdered)
+
+	_, err = replay.Exchange([]byte{0xe0, 0xd8, 0x00, 0x00, 0x00})
+	assert.ErrorIs(t, err, ErrReplayMismatch)
+
+	info, err := GetAppAndVersion(replay)
+	require.NoError(t, err)
+	assert.True(t, info.IsDashboard())
+	assert.Equal(t, 1, replay.Remaining())
+
+	err = OpenApp(replay, "Cosmos")
+	assert.ErrorIs(t, err, ErrAppNotInstalled)
+
+	_, err = GetAppAndVersion(replay)
+	assert.ErrorIs(t, err, ErrReplayExhausted)
+}
+
+func TestReplayKeyed(t *testing.T) {
+	transcript := `
+=> e001000000
+<= 019000
+=> e001000000
+<= 029000
+=> e002000000
+!! device disconnected
+`
+	entries, err := ParseTranscript(strings.NewReader(transcript))
+	require.NoError(t, err)
+	replay := NewReplayDevice(entries, ReplayKeyed)
+
+	_, err = replay.Exchange([]byte{0xe0, 0x02, 0x00, 0x00, 0x00})
+	assert.EqualError(t, err, "device disconnected")
+
+	var replies []byte
+	for i := 0; i < 3; i++ {
+		response, err := replay.Exchange([]byte{0xe0, 0x01, 0x00, 0x00, 0x00})
+		require.NoError(t, err)
+		replies = append(replies, response...)
+	}
+	assert.Equal(t, []byte{0x01, 0x02, 0x02}, replies)
+
+	_, err = replay.Exchange([]byte{0xe0, 0x03, 0x00, 0x00, 0x00})
+	assert.ErrorIs(t, err, ErrReplayMismatch)
+}
+
+func TestReplaySimulateTiming(t *testing.T) {
+	entries := []TranscriptEntry{{
+		Command:  []byte{0xe0, 0x01, 0x00, 0x00, 0x00},
+		Response: []byte{0x90, 0x00},
+		Elapsed:  20 * time.Millisecond,
+	}}
+	replay := NewReplayDevice(entries, ReplayOrdered)
+	replay.SimulateTiming = true
+
+	start := time.Now()
+	_, err := replay.Exchange(entries[0].Command)
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, time.Since(start), 20*time.Millisecond)
+}
@@ -0,0 +1,319 @@
+/*******************************************************************************
+*   (c) Zondax AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+********************************************************************************/
+
+package ledger_go
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	ErrMsgInvalidVersion           = "invalid version"
+	ErrMsgInvalidVersionConstraint = "invalid version constraint"
+	ErrMsgIncompatibleAppVersion   = "incompatible app version"
+)
+
+var (
+	ErrInvalidVersion           = errors.New(ErrMsgInvalidVersion)
+	ErrInvalidVersionConstraint = errors.New(ErrMsgInvalidVersionConstraint)
+	ErrIncompatibleAppVersion   = errors.New(ErrMsgIncompatibleAppVersion)
+)
+
+// Version is a semantic version as reported by apps and firmware.
+type Version struct {
+	Major      uint64
+	Minor      uint64
+	Patch      uint64
+	Prerelease string
+}
+
+// ParseVersion parses versions such as "2.34.12", "v1.2" or "1.0.0-rc1".
+// Missing minor or patch components default to zero and build metadata is ignored.
+func ParseVersion(s string) (Version, error) {
+	text := strings.TrimPrefix(strings.TrimSpace(s), "v")
+
+	if i := strings.IndexByte(text, '+'); i >= 0 {
+		text = text[:i]
+	}
+
+	var version Version
+	if i := strings.IndexByte(text, '-'); i >= 0 {
+		version.Prerelease = text[i+1:]
+		text = text[:i]
+		if version.Prerelease == "" {
+			return Version{}, fmt.Errorf("%w: %q", ErrInvalidVersion, s)
+		}
+	}
+
+	parts := strings.Split(text, ".")
+	if len(parts) > 3 {
+		return Version{}, fmt.Errorf("%w: %q", ErrInvalidVersion, s)
+	}
+
+	components := []*uint64{&version.Major, &version.Minor, &version.Patch}
+	for i, part := range parts {
+		value, err := strconv.ParseUint(part, 10, 64)
+		if err != nil {
+			return Version{}, fmt.Errorf("%w: %q", ErrInvalidVersion, s)
+		}
+		*components[i] = value
+	}
+
+	return version, nil
+}
+
+func (v Version) String() string {
+	result := fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+	if v.Prerelease != "" {
+		result += "-" + v.Prerelease
+	}
+	return result
+}
+
+// Compare returns -1, 0 or 1 when v is lower, equal or greater than other.
+func (v Version) Compare(other Version) int {
+	if c := compareUint(v.Major, other.Major); c != 0 {
+		return c
+	}
+	if c := compareUint(v.Minor, other.Minor); c != 0 {
+		return c
+	}
+	if c := compareUint(v.Patch, other.Patch); c != 0 {
+		return c
+	}
+	return comparePrerelease(v.Prerelease, other.Prerelease)
+}
+
+func compareUint(a, b uint64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// comparePrerelease follows semver precedence: a release is greater than any prerelease,
+// numeric identifiers compare numerically and are lower than alphanumeric ones.
+func comparePrerelease(a, b string) int {
+	switch {
+	case a == b:
+		return 0
+	case a == "":
+		return 1
+	case b == "":
+		return -1
+	}
+
+	aParts := strings.Split(a, ".")
+	bParts := strings.Split(b, ".")
+	for i := 0; i < len(aParts) && i < len(bParts); i++ {
+		aNum, aErr := strconv.ParseUint(aParts[i], 10, 64)
+		bNum, bErr := strconv.ParseUint(bParts[i], 10, 64)
+
+		var c int
+		switch {
+		case aErr == nil && bErr == nil:
+			c = compareUint(aNum, bNum)
+		case aErr == nil:
+			c = -1
+		case bErr == nil:
+			c = 1
+		default:
+			c = strings.Compare(aParts[i], bParts[i])
+		}
+		if c != 0 {
+			return c
+		}
+	}
+
+	return compareUint(uint64(len(aParts)), uint64(len(bParts)))
+}
+
+// Version returns the app version as a Version.
+func (v VersionInfo) Version() Version {
+	return Version{Major: uint64(v.Major), Minor: uint64(v.Minor), Patch: uint64(v.Patch)}
+}
+
+type versionComparator struct {
+	operator string
+	version  Version
+}
+
+// VersionConstraint is a set of comparisons that must all hold, e.g. ">=2.1.0 <3.0.0".
+type VersionConstraint struct {
+	text        string
+	comparators []versionComparator
+}
+
+// operatorSpacing matches the spaces allowed between an operator and its version
+var operatorSpacing = regexp.MustCompile(`([<>!=]=?)\s+`)
+
+// ParseVersionConstraint parses space or comma separated comparisons using
+// the operators =, ==, !=, >, >=, < and <=. A bare version means equality.
+// Operators may be followed by spaces, as in ">= 2.0.0".
+func ParseVersionConstraint(s string) (VersionConstraint, error) {
+	fields := strings.FieldsFunc(operatorSpacing.ReplaceAllString(s, "$1"), func(r rune) bool { return r == ' ' || r == ',' })
+	if len(fields) == 0 {
+		return VersionConstraint{}, fmt.Errorf("%w: %q", ErrInvalidVersionConstraint, s)
+	}
+
+	constraint := VersionConstraint{text: strings.TrimSpace(s)}
+	for _, field := range fields {
+		operator := "="
+		for _, op := range []string{">=", "<=", "!=", "==", ">", "<", "="} {
+			if strings.HasPrefix(field, op) {
+				operator = op
+				field = field[len(op):]
+				break
+			}
+		}
+		if operator == "==" {
+			operator = "="
+		}
+
+		version, err := ParseVersion(field)
+		if err != nil {
+			return VersionConstraint{}, fmt.Errorf("%w: %q: %w", ErrInvalidVersionConstraint, s, err)
+		}
+		constraint.comparators = append(constraint.comparators, versionComparator{operator: operator, version: version})
+	}
+
+	return constraint, nil
+}
+
+// Check reports whether version satisfies every comparison of the constraint.
+func (c VersionConstraint) Check(version Version) bool {
+	_, ok := c.failed(version)
+	return !ok
+}
+
+// failed returns the first comparison that version does not satisfy
+func (c VersionConstraint) failed(version Version) (versionComparator, bool) {
+	for _, comparator := range c.comparators {
+		if !comparator.check(version) {
+			return comparator, true
+		}
+	}
+	return versionComparator{}, false
+}
+
+func (c versionComparator) check(version Version) bool {
+	cmp := version.Compare(c.version)
+
+	switch c.operator {
+	case "=":
+		return cmp == 0
+	case "!=":
+		return cmp != 0
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	}
+	return false
+}
+
+func (c VersionConstraint) String() string {
+	return c.text
+}
+
+// AppVersionError reports an installed app version that does not satisfy the required constraint.
+type AppVersionError struct {
+	App       string
+	Installed Version
+	Required  VersionConstraint
+}
+
+func (e *AppVersionError) Error() string {
+	msg := fmt.Sprintf("%s: %s %s is installed but %s is required",
+		ErrMsgIncompatibleAppVersion, e.App, e.Installed, e.Required)
+
+	// Advise based on the comparison that failed, a maximum version means going back
+	comparator, ok := e.Required.failed(e.Installed)
+	if !ok {
+		return msg
+	}
+	switch comparator.operator {
+	case ">", ">=":
+		return msg + ", please update the app"
+	case "<", "<=":
+		return msg + ", please install an older version of the app"
+	case "=":
+		if e.Installed.Compare(comparator.version) < 0 {
+			return msg + ", please update the app"
+		}
+		return msg + ", please install an older version of the app"
+	}
+	return msg
+}
+
+func (e *AppVersionError) Is(target error) bool {
+	return target == ErrIncompatibleAppVersion
+}
+
+// RequireAppVersion checks that the running app satisfies constraint (e.g. ">=2.34.0 <3.0.0").
+// It returns an *AppVersionError when the installed version is not supported.
+func RequireAppVersion(device LedgerDevice, constraint string) error {
+	required, err := ParseVersionConstraint(constraint)
+	if err != nil {
+		return err
+	}
+
+	info, err := GetAppAndVersion(device)
+	if err != nil {
+		return err
+	}
+
+	installed, err := ParseVersion(info.Version)
+	if err != nil {
+		return err
+	}
+
+	return checkAppVersion(info.Name, installed, required)
+}
+
+// RequireVersion checks that the app version reported by GET_VERSION satisfies constraint.
+func (c *AppClient) RequireVersion(constraint string) error {
+	required, err := ParseVersionConstraint(constraint)
+	if err != nil {
+		return err
+	}
+
+	info, err := c.GetVersion()
+	if err != nil {
+		return err
+	}
+
+	return checkAppVersion(fmt.Sprintf("app (CLA 0x%02X)", c.config.CLA), info.Version(), required)
+}
+
+func checkAppVersion(app string, installed Version, required VersionConstraint) error {
+	if !required.Check(installed) {
+		return &AppVersionError{App: app, Installed: installed, Required: required}
+	}
+	return nil
+}
@@ -0,0 +1,134 @@
+/*******************************************************************************
+*   (c) Zondax AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+********************************************************************************/
+
+package ledger_go
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseVersion(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected Version
+	}{
+		{"2.34.12", Version{Major: 2, Minor: 34, Patch: 12}},
+		{"v1.2", Version{Major: 1, Minor: 2}},
+		{"1.0.0-rc.1+build5", Version{Major: 1, Prerelease: "rc.1"}},
+		{"3", Version{Major: 3}},
+	}
+
+	for _, tt := range tests {
+		version, err := ParseVersion(tt.input)
+		require.NoError(t, err, tt.input)
+		assert.Equal(t, tt.expected, version, tt.input)
+	}
+
+	for _, input := range []string{"", "a.b.c", "1.2.3.4", "1.2.3-", "1..2"} {
+		_, err := ParseVersion(input)
+		assert.ErrorIs(t, err, ErrInvalidVersion, input)
+	}
+}
+
+func TestVersionCompare(t *testing.T) {
+	ordered := []string{"1.0.0-alpha", "1.0.0-alpha.1", "1.0.0-alpha.beta", "1.0.0-beta.2", "1.0.0-beta.11", "1.0.0", "1.0.1", "1.2.0", "2.0.0"}
+
+	for i := range ordered {
+		for j := range ordered {
+			a, _ := ParseVersion(ordered[i])
+			b, _ := ParseVersion(ordered[j])
+			assert.Equal(t, compareUint(uint64(i), uint64(j)), a.Compare(b), "%s vs %s", ordered[i], ordered[j])
+		}
+	}
+}
+
+func TestVersionConstraint(t *testing.T) {
+	tests := []struct {
+		constraint string
+		version    string
+		expected   bool
+	}{
+		{">=2.34.0", "2.34.0", true},
+		{">=2.34.0", "2.33.9", false},
+		{">=2.0.0 <3.0.0", "2.99.1", true},
+		{">=2.0.0, <3.0.0", "3.0.0", false},
+		{"!=1.2.3", "1.2.3", false},
+		{"1.2.3", "1.2.3", true},
+		{"==1.2", "1.2.0", true},
+		{">1.0.0", "1.0.0-rc1", false},
+		{"<=1.0.0", "1.0.0", true},
+		{">= 2.0.0, < 3.0.0", "2.5.0", true},
+		{"== 1.2.3", "1.2.4", false},
+	}
+
+	for _, tt := range tests {
+		constraint, err := ParseVersionConstraint(tt.constraint)
+		require.NoError(t, err)
+		version, err := ParseVersion(tt.version)
+		require.NoError(t, err)
+		assert.Equal(t, tt.expected, constraint.Check(version), "%s %s", tt.constraint, tt.version)
+	}
+
+	for _, input := range []string{"", ">=", ">= ", ">=x.y", "~1.2"} {
+		_, err := ParseVersionConstraint(input)
+		assert.ErrorIs(t, err, ErrInvalidVersionConstraint, input)
+	}
+}
+
+func appVersionDevice(version string) exchangeFunc {
+	return func([]byte) ([]byte, error) {
+		reply := append([]byte{0x01, 0x06}, "Cosmos"...)
+		reply = append(reply, byte(len(version)))
+		return append(reply, version...), nil
+	}
+}
+
+func TestRequireAppVersion(t *testing.T) {
+	assert.NoError(t, RequireAppVersion(appVersionDevice("2.34.12"), ">=2.34.0"))
+
+	err := RequireAppVersion(appVersionDevice("2.33.1"), ">=2.34.0")
+	assert.ErrorIs(t, err, ErrIncompatibleAppVersion)
+	assert.EqualError(t, err, "incompatible app version: Cosmos 2.33.1 is installed but >=2.34.0 is required, please update the app")
+
+	var versionErr *AppVersionError
+	require.True(t, errors.As(err, &versionErr))
+	assert.Equal(t, Version{Major: 2, Minor: 33, Patch: 1}, versionErr.Installed)
+
+	err = RequireAppVersion(appVersionDevice("3.0.0"), ">=2.34.0 <3.0.0")
+	assert.EqualError(t, err, "incompatible app version: Cosmos 3.0.0 is installed but >=2.34.0 <3.0.0 is required, please install an older version of the app")
+
+	err = RequireAppVersion(appVersionDevice("2.34.0"), "=2.34.1")
+	assert.EqualError(t, err, "incompatible app version: Cosmos 2.34.0 is installed but =2.34.1 is required, please update the app")
+
+	err = RequireAppVersion(appVersionDevice("2.34.1"), "!=2.34.1")
+	assert.EqualError(t, err, "incompatible app version: Cosmos 2.34.1 is installed but !=2.34.1 is required")
+
+	assert.ErrorIs(t, RequireAppVersion(appVersionDevice("2.34.12"), "latest"), ErrInvalidVersionConstraint)
+	assert.ErrorIs(t, RequireAppVersion(appVersionDevice("beta"), ">=1.0.0"), ErrInvalidVersion)
+}
+
+func TestAppClientRequireVersion(t *testing.T) {
+	client := NewAppClient(exchangeFunc(func([]byte) ([]byte, error) {
+		return []byte{0x00, 0x01, 0x02, 0x03}, nil
+	}), AppConfig{CLA: 0x55})
+
+	assert.NoError(t, client.RequireVersion(">=1.2.0"))
+	assert.ErrorIs(t, client.RequireVersion(">=1.3.0"), ErrIncompatibleAppVersion)
+}
@@ -0,0 +1,308 @@
+/*******************************************************************************
+*   (c) Zondax AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+********************************************************************************/
+
+package ledger_go
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+const (
+	SwExecutionError  = 0x6400
+	SwWrongLength     = 0x6700
+	SwInvalidData     = 0x6A80
+	SwInsNotSupported = 0x6D00
+	SwClaNotSupported = 0x6E00
+)
+
+// The framing encodes the reply length on two bytes
+const virtualReplyLimit = 0xFFFF
+
+const ErrMsgDeviceClosed = "device closed"
+
+var ErrDeviceClosed = errors.New(ErrMsgDeviceClosed)
+
+// Command is a parsed APDU command.
+type Command struct {
+	CLA  byte
+	INS  byte
+	P1   byte
+	P2   byte
+	Data []byte
+}
+
+// ParseCommand splits a raw command into its header and data.
+func ParseCommand(command []byte) (*Command, error) {
+	if err := ValidateCommand(command); err != nil {
+		return nil, err
+	}
+
+	return &Command{
+		CLA:  command[0],
+		INS:  command[1],
+		P1:   command[2],
+		P2:   command[3],
+		Data: append([]byte{}, command[5:]...),
+	}, nil
+}
+
+// Bytes returns the raw command.
+func (c *Command) Bytes() []byte {
+	return append([]byte{c.CLA, c.INS, c.P1, c.P2, byte(len(c.Data))}, c.Data...)
+}
+
+// VirtualHandler answers a command with data and a status word.
+type VirtualHandler func(command *Command) ([]byte, uint16)
+
+// Prompter answers a prompt in place of the simulated user. cancel is closed when the device is closed.
+type Prompter func(command *Command, cancel <-chan struct{}) bool
+
+// UserAction is the simulated user response to a prompt.
+type UserAction struct {
+	Delay  time.Duration
+	Reject bool
+}
+
+// VirtualDevice is a LedgerDevice running Go handlers registered per CLA/INS.
+// Handlers keep their own state, and call Prompt to simulate the user reviewing a request.
+type VirtualDevice struct {
+	// busy serializes commands, as on a real device
+	busy     sync.Mutex
+	mu       sync.Mutex
+	handlers map[[2]byte]VirtualHandler
+	classes  map[byte]bool
+	action   UserAction
+	queued   []UserAction
+	prompter Prompter
+	closed   chan struct{}
+
+	// Framed sends commands and replies through the HID framing, as a real device would
+	Framed bool
+}
+
+func NewVirtualDevice() *VirtualDevice {
+	return &VirtualDevice{
+		handlers: make(map[[2]byte]VirtualHandler),
+		classes:  make(map[byte]bool),
+		closed:   make(chan struct{}),
+	}
+}
+
+// Handle registers the handler for the instruction, replacing any previous one.
+func (d *VirtualDevice) Handle(cla byte, ins byte, handler VirtualHandler) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.handlers[[2]byte{cla, ins}] = handler
+	d.classes[cla] = true
+}
+
+// SetUserAction sets how the simulated user answers prompts once queued actions are used up.
+// By default prompts are approved immediately.
+func (d *VirtualDevice) SetUserAction(action UserAction) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.action = action
+}
+
+// QueueUserActions sets the answers to the next prompts, in order.
+func (d *VirtualDevice) QueueUserActions(actions ...UserAction) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.queued = append(d.queued, actions...)
+}
+
+// SetPrompter hands prompts over to prompter, such as an emulator screen, instead of the
+// simulated user actions. A nil prompter restores them.
+func (d *VirtualDevice) SetPrompter(prompter Prompter) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.prompter = prompter
+}
+
+// Prompt simulates showing command to the user and returns whether it was approved.
+func (d *VirtualDevice) Prompt(command *Command) bool {
+	d.mu.Lock()
+	if prompter := d.prompter; prompter != nil {
+		closed := d.closed
+		d.mu.Unlock()
+		return prompter(command, closed)
+	}
+
+	action := d.action
+	if len(d.queued) > 0 {
+		action = d.queued[0]
+		d.queued = d.queued[1:]
+	}
+	closed := d.closed
+	d.mu.Unlock()
+
+	select {
+	case <-time.After(action.Delay):
+		return !action.Reject
+	case <-closed:
+		return false
+	}
+}
+
+// Process answers a raw command with the raw reply, status word included.
+func (d *VirtualDevice) Process(command []byte) []byte {
+	parsed, err := ParseCommand(command)
+	if err != nil {
+		return statusReply(nil, SwWrongLength)
+	}
+
+	d.mu.Lock()
+	handler, ok := d.handlers[[2]byte{parsed.CLA, parsed.INS}]
+	knownClass := d.classes[parsed.CLA]
+	d.mu.Unlock()
+
+	if !ok {
+		if knownClass {
+			return statusReply(nil, SwInsNotSupported)
+		}
+		return statusReply(nil, SwClaNotSupported)
+	}
+
+	d.busy.Lock()
+	data, sw := handler(parsed)
+	d.busy.Unlock()
+
+	if len(data) > virtualReplyLimit-2 {
+		return statusReply(nil, SwExecutionError)
+	}
+	return statusReply(data, sw)
+}
+
+func statusReply(data []byte, sw uint16) []byte {
+	return append(append([]byte{}, data...), byte(sw>>8), byte(sw))
+}
+
+func (d *VirtualDevice) Exchange(command []byte) ([]byte, error) {
+	if d.isClosed() {
+		return nil, ErrDeviceClosed
+	}
+
+	if err := ValidateCommand(command); err != nil {
+		return nil, err
+	}
+
+	if !d.Framed {
+		return ParseResponse(d.Process(command))
+	}
+
+	received, err := passFrames(command)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := passFrames(d.Process(received))
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseResponse(response)
+}
+
+// passFrames wraps message into HID packets and reassembles it on the other side.
+func passFrames(message []byte) ([]byte, error) {
+	frames, err := WrapCommandAPDU(Channel, message, PacketSize)
+	if err != nil {
+		return nil, err
+	}
+
+	pipe := make(chan []byte, len(frames)/PacketSize)
+	for offset := 0; offset < len(frames); offset += PacketSize {
+		pipe <- frames[offset : offset+PacketSize]
+	}
+	close(pipe)
+
+	return UnwrapResponseAPDU(Channel, pipe, PacketSize)
+}
+
+// Close rejects pending prompts and makes further exchanges fail with ErrDeviceClosed.
+// Handler state is kept when the device is connected again through a ledgertest admin.
+func (d *VirtualDevice) Close() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if !d.isClosedLocked() {
+		close(d.closed)
+	}
+	return nil
+}
+
+// Reopen lets a closed device be used again, as ledgertest admins do when connecting it.
+func (d *VirtualDevice) Reopen() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.isClosedLocked() {
+		d.closed = make(chan struct{})
+	}
+}
+
+func (d *VirtualDevice) isClosed() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.isClosedLocked()
+}
+
+func (d *VirtualDevice) isClosedLocked() bool {
+	select {
+	case <-d.closed:
+		return true
+	default:
+		return false
+	}
+}
+
+// VirtualPayloadHandler handles a payload sent in chunks, once the last chunk arrived.
+type VirtualPayloadHandler func(command *Command, payload []byte) ([]byte, uint16)
+
+// ChunkedHandler accumulates the chunks sent with PayloadInit, PayloadAdd and PayloadLast in P1,
+// the way AppClient.Sign sends them, and calls handler with the whole payload.
+func ChunkedHandler(handler VirtualPayloadHandler) VirtualHandler {
+	var payload []byte
+	started := false
+
+	return func(command *Command) ([]byte, uint16) {
+		switch command.P1 {
+		case PayloadInit:
+			payload = append([]byte{}, command.Data...)
+			started = true
+			return nil, SwOK
+
+		case PayloadAdd, PayloadLast:
+			if !started {
+				return nil, SwConditionsNotSatisfied
+			}
+			payload = append(payload, command.Data...)
+			if command.P1 == PayloadAdd {
+				return nil, SwOK
+			}
+
+			whole := payload
+			payload, started = nil, false
+			return handler(command, whole)
+
+		default:
+			return nil, SwInvalidData
+		}
+	}
+}
@@ -0,0 +1,132 @@
+/*******************************************************************************
+*   (c) Zondax AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+********************************************************************************/
+
+package ledger_go
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const virtualCLA = 0x55
+
+// newVirtualApp returns a device running a minimal Zondax-style app:
+// GET_VERSION, and SIGN replying with the hash of the message after approval.
+func newVirtualApp() *VirtualDevice {
+	device := NewVirtualDevice()
+	device.Handle(virtualCLA, InsGetVersion, func(_ *Command) ([]byte, uint16) {
+		return []byte{0x00, 0x00, 0x01, 0x00, 0x02, 0x00, 0x03, 0x00, 0x33, 0x00, 0x00, 0x04}, SwOK
+	})
+
+	device.Handle(virtualCLA, InsSign, ChunkedHandler(func(command *Command, payload []byte) ([]byte, uint16) {
+		if !device.Prompt(command) {
+			return nil, SwCommandNotAllowed
+		}
+		// The first chunk holds the path
+		digest := sha256.Sum256(payload[20:])
+		return digest[:], SwOK
+	}))
+	return device
+}
+
+func TestParseCommand(t *testing.T) {
+	raw := []byte{0xe0, 0x02, 0x01, 0x00, 0x02, 0xaa, 0xbb}
+	command, err := ParseCommand(raw)
+	require.NoError(t, err)
+	assert.Equal(t, &Command{CLA: 0xe0, INS: 0x02, P1: 0x01, Data: []byte{0xaa, 0xbb}}, command)
+	assert.Equal(t, raw, command.Bytes())
+
+	_, err = ParseCommand([]byte{0xe0, 0x02, 0x01, 0x00, 0x03})
+	assert.ErrorIs(t, err, ErrCommandLengthMismatch)
+}
+
+func TestVirtualDeviceDispatch(t *testing.T) {
+	device := newVirtualApp()
+
+	assert.Equal(t, []byte{0x6e, 0x00}, device.Process([]byte{0xe0, 0x01, 0x00, 0x00, 0x00}))
+	assert.Equal(t, []byte{0x6d, 0x00}, device.Process([]byte{virtualCLA, 0x09, 0x00, 0x00, 0x00}))
+	assert.Equal(t, []byte{0x67, 0x00}, device.Process([]byte{virtualCLA, 0x00}))
+
+	client := NewAppClient(device, AppConfig{CLA: virtualCLA})
+	version, err := client.GetVersion()
+	require.NoError(t, err)
+	assert.Equal(t, "1.2.3", version.String())
+
+	_, err = device.Exchange([]byte{virtualCLA, InsSign, PayloadLast, 0x00, 0x00})
+	assert.EqualError(t, err, ErrorMessage(SwConditionsNotSatisfied))
+}
+
+func TestVirtualDeviceSign(t *testing.T) {
+	for _, framed := range []bool{false, true} {
+		device := newVirtualApp()
+		device.Framed = framed
+		client := NewAppClient(device, AppConfig{CLA: virtualCLA, ChunkSize: 100})
+
+		message := bytes.Repeat([]byte("message"), 100)
+		signature, err := client.Sign(testPath, message)
+		require.NoError(t, err)
+
+		digest := sha256.Sum256(message)
+		assert.Equal(t, digest[:], signature, "framed %v", framed)
+	}
+}
+
+func TestVirtualDeviceUserActions(t *testing.T) {
+	device := newVirtualApp()
+	device.QueueUserActions(UserAction{Delay: 30 * time.Millisecond}, UserAction{Reject: true})
+	client := NewAppClient(device, AppConfig{CLA: virtualCLA})
+
+	start := time.Now()
+	_, err := client.Sign(testPath, []byte("first"))
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, time.Since(start), 30*time.Millisecond)
+
+	_, err = client.Sign(testPath, []byte("second"))
+	assert.ErrorIs(t, err, ErrUserRefused)
+
+	_, err = client.Sign(testPath, []byte("third"))
+	assert.NoError(t, err)
+}
+
+func TestVirtualDeviceClose(t *testing.T) {
+	device := newVirtualApp()
+	device.SetUserAction(UserAction{Delay: time.Hour})
+	client := NewAppClient(device, AppConfig{CLA: virtualCLA})
+
+	done := make(chan error)
+	go func() {
+		_, err := client.Sign(testPath, []byte("message"))
+		done <- err
+	}()
+
+	time.Sleep(20 * time.Millisecond)
+	require.NoError(t, device.Close())
+
+	select {
+	case err := <-done:
+		assert.ErrorIs(t, err, ErrUserRefused)
+	case <-time.After(time.Second):
+		t.Fatal("pending prompt was not rejected on Close")
+	}
+
+	_, err := device.Exchange([]byte{virtualCLA, InsGetVersion, 0x00, 0x00, 0x00})
+	assert.ErrorIs(t, err, ErrDeviceClosed)
+}
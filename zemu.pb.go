@@ -1,17 +1,12 @@
 // Code generated by protoc-gen-go. DO NOT EDIT.
 // versions:
-// 	protoc-gen-go v1.23.0
+// 	protoc-gen-go v1.34.2
 // 	protoc        (unknown)
 // source: zemu.proto
 
 package ledger_go
 
 import (
-	context "context"
-	proto "github.com/golang/protobuf/proto"
-	grpc "google.golang.org/grpc"
-	codes "google.golang.org/grpc/codes"
-	status "google.golang.org/grpc/status"
 	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
 	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
 	reflect "reflect"
@@ -25,9 +20,57 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
-// This is a compile-time assertion that a sufficiently up-to-date version
-// of the legacy proto package is being used.
-const _ = proto.ProtoPackageIsVersion4
+type ZemuButton int32
+
+const (
+	ZemuButton_BUTTON_NONE  ZemuButton = 0
+	ZemuButton_BUTTON_LEFT  ZemuButton = 1
+	ZemuButton_BUTTON_RIGHT ZemuButton = 2
+	ZemuButton_BUTTON_BOTH  ZemuButton = 3
+)
+
+// Enum value maps for ZemuButton.
+var (
+	ZemuButton_name = map[int32]string{
+		0: "BUTTON_NONE",
+		1: "BUTTON_LEFT",
+		2: "BUTTON_RIGHT",
+		3: "BUTTON_BOTH",
+	}
+	ZemuButton_value = map[string]int32{
+		"BUTTON_NONE":  0,
+		"BUTTON_LEFT":  1,
+		"BUTTON_RIGHT": 2,
+		"BUTTON_BOTH":  3,
+	}
+)
+
+func (x ZemuButton) Enum() *ZemuButton {
+	p := new(ZemuButton)
+	*p = x
+	return p
+}
+
+func (x ZemuButton) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (ZemuButton) Descriptor() protoreflect.EnumDescriptor {
+	return file_zemu_proto_enumTypes[0].Descriptor()
+}
+
+func (ZemuButton) Type() protoreflect.EnumType {
+	return &file_zemu_proto_enumTypes[0]
+}
+
+func (x ZemuButton) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use ZemuButton.Descriptor instead.
+func (ZemuButton) EnumDescriptor() ([]byte, []int) {
+	return file_zemu_proto_rawDescGZIP(), []int{0}
+}
 
 type ExchangeRequest struct {
 	state         protoimpl.MessageState
@@ -123,6 +166,400 @@ func (x *ExchangeReply) GetReply() []byte {
 	return nil
 }
 
+type ButtonRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Button ZemuButton `protobuf:"varint,1,opt,name=button,proto3,enum=ledger_go.ZemuButton" json:"button,omitempty"`
+}
+
+func (x *ButtonRequest) Reset() {
+	*x = ButtonRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_zemu_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ButtonRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ButtonRequest) ProtoMessage() {}
+
+func (x *ButtonRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_zemu_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ButtonRequest.ProtoReflect.Descriptor instead.
+func (*ButtonRequest) Descriptor() ([]byte, []int) {
+	return file_zemu_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *ButtonRequest) GetButton() ZemuButton {
+	if x != nil {
+		return x.Button
+	}
+	return ZemuButton_BUTTON_NONE
+}
+
+// Navigates through the review screens of the pending request and approves or rejects it
+type ClickThroughRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Reject bool `protobuf:"varint,1,opt,name=reject,proto3" json:"reject,omitempty"`
+	// Maximum number of screens to go through, a default limit applies when zero
+	MaxScreens uint32 `protobuf:"varint,2,opt,name=max_screens,json=maxScreens,proto3" json:"max_screens,omitempty"`
+}
+
+func (x *ClickThroughRequest) Reset() {
+	*x = ClickThroughRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_zemu_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ClickThroughRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ClickThroughRequest) ProtoMessage() {}
+
+func (x *ClickThroughRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_zemu_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ClickThroughRequest.ProtoReflect.Descriptor instead.
+func (*ClickThroughRequest) Descriptor() ([]byte, []int) {
+	return file_zemu_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *ClickThroughRequest) GetReject() bool {
+	if x != nil {
+		return x.Reject
+	}
+	return false
+}
+
+func (x *ClickThroughRequest) GetMaxScreens() uint32 {
+	if x != nil {
+		return x.MaxScreens
+	}
+	return 0
+}
+
+type ControlReply struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Text on screen after the action
+	Screen []string `protobuf:"bytes,1,rep,name=screen,proto3" json:"screen,omitempty"`
+}
+
+func (x *ControlReply) Reset() {
+	*x = ControlReply{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_zemu_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ControlReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ControlReply) ProtoMessage() {}
+
+func (x *ControlReply) ProtoReflect() protoreflect.Message {
+	mi := &file_zemu_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ControlReply.ProtoReflect.Descriptor instead.
+func (*ControlReply) Descriptor() ([]byte, []int) {
+	return file_zemu_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *ControlReply) GetScreen() []string {
+	if x != nil {
+		return x.Screen
+	}
+	return nil
+}
+
+type SnapshotRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *SnapshotRequest) Reset() {
+	*x = SnapshotRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_zemu_proto_msgTypes[5]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SnapshotRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SnapshotRequest) ProtoMessage() {}
+
+func (x *SnapshotRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_zemu_proto_msgTypes[5]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SnapshotRequest.ProtoReflect.Descriptor instead.
+func (*SnapshotRequest) Descriptor() ([]byte, []int) {
+	return file_zemu_proto_rawDescGZIP(), []int{5}
+}
+
+type SnapshotReply struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// PNG image of the screen
+	Image  []byte   `protobuf:"bytes,1,opt,name=image,proto3" json:"image,omitempty"`
+	Screen []string `protobuf:"bytes,2,rep,name=screen,proto3" json:"screen,omitempty"`
+}
+
+func (x *SnapshotReply) Reset() {
+	*x = SnapshotReply{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_zemu_proto_msgTypes[6]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SnapshotReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SnapshotReply) ProtoMessage() {}
+
+func (x *SnapshotReply) ProtoReflect() protoreflect.Message {
+	mi := &file_zemu_proto_msgTypes[6]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SnapshotReply.ProtoReflect.Descriptor instead.
+func (*SnapshotReply) Descriptor() ([]byte, []int) {
+	return file_zemu_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *SnapshotReply) GetImage() []byte {
+	if x != nil {
+		return x.Image
+	}
+	return nil
+}
+
+func (x *SnapshotReply) GetScreen() []string {
+	if x != nil {
+		return x.Screen
+	}
+	return nil
+}
+
+type WaitForTextRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Text      string `protobuf:"bytes,1,opt,name=text,proto3" json:"text,omitempty"`
+	TimeoutMs uint32 `protobuf:"varint,2,opt,name=timeout_ms,json=timeoutMs,proto3" json:"timeout_ms,omitempty"`
+}
+
+func (x *WaitForTextRequest) Reset() {
+	*x = WaitForTextRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_zemu_proto_msgTypes[7]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *WaitForTextRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WaitForTextRequest) ProtoMessage() {}
+
+func (x *WaitForTextRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_zemu_proto_msgTypes[7]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WaitForTextRequest.ProtoReflect.Descriptor instead.
+func (*WaitForTextRequest) Descriptor() ([]byte, []int) {
+	return file_zemu_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *WaitForTextRequest) GetText() string {
+	if x != nil {
+		return x.Text
+	}
+	return ""
+}
+
+func (x *WaitForTextRequest) GetTimeoutMs() uint32 {
+	if x != nil {
+		return x.TimeoutMs
+	}
+	return 0
+}
+
+type WaitForTextReply struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Found  bool     `protobuf:"varint,1,opt,name=found,proto3" json:"found,omitempty"`
+	Screen []string `protobuf:"bytes,2,rep,name=screen,proto3" json:"screen,omitempty"`
+}
+
+func (x *WaitForTextReply) Reset() {
+	*x = WaitForTextReply{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_zemu_proto_msgTypes[8]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *WaitForTextReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WaitForTextReply) ProtoMessage() {}
+
+func (x *WaitForTextReply) ProtoReflect() protoreflect.Message {
+	mi := &file_zemu_proto_msgTypes[8]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WaitForTextReply.ProtoReflect.Descriptor instead.
+func (*WaitForTextReply) Descriptor() ([]byte, []int) {
+	return file_zemu_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *WaitForTextReply) GetFound() bool {
+	if x != nil {
+		return x.Found
+	}
+	return false
+}
+
+func (x *WaitForTextReply) GetScreen() []string {
+	if x != nil {
+		return x.Screen
+	}
+	return nil
+}
+
+type ResetRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *ResetRequest) Reset() {
+	*x = ResetRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_zemu_proto_msgTypes[9]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ResetRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ResetRequest) ProtoMessage() {}
+
+func (x *ResetRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_zemu_proto_msgTypes[9]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ResetRequest.ProtoReflect.Descriptor instead.
+func (*ResetRequest) Descriptor() ([]byte, []int) {
+	return file_zemu_proto_rawDescGZIP(), []int{9}
+}
+
 var File_zemu_proto protoreflect.FileDescriptor
 
 var file_zemu_proto_rawDesc = []byte{
@@ -132,13 +569,66 @@ var file_zemu_proto_rawDesc = []byte{
 	0x6d, 0x6d, 0x61, 0x6e, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x07, 0x63, 0x6f, 0x6d,
 	0x6d, 0x61, 0x6e, 0x64, 0x22, 0x25, 0x0a, 0x0d, 0x45, 0x78, 0x63, 0x68, 0x61, 0x6e, 0x67, 0x65,
 	0x52, 0x65, 0x70, 0x6c, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x72, 0x65, 0x70, 0x6c, 0x79, 0x18, 0x01,
-	0x20, 0x01, 0x28, 0x0c, 0x52, 0x05, 0x72, 0x65, 0x70, 0x6c, 0x79, 0x32, 0x51, 0x0a, 0x0b, 0x5a,
-	0x65, 0x6d, 0x75, 0x43, 0x6f, 0x6d, 0x6d, 0x61, 0x6e, 0x64, 0x12, 0x42, 0x0a, 0x08, 0x45, 0x78,
-	0x63, 0x68, 0x61, 0x6e, 0x67, 0x65, 0x12, 0x1a, 0x2e, 0x6c, 0x65, 0x64, 0x67, 0x65, 0x72, 0x5f,
-	0x67, 0x6f, 0x2e, 0x45, 0x78, 0x63, 0x68, 0x61, 0x6e, 0x67, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65,
-	0x73, 0x74, 0x1a, 0x18, 0x2e, 0x6c, 0x65, 0x64, 0x67, 0x65, 0x72, 0x5f, 0x67, 0x6f, 0x2e, 0x45,
-	0x78, 0x63, 0x68, 0x61, 0x6e, 0x67, 0x65, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x22, 0x00, 0x62, 0x06,
-	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+	0x20, 0x01, 0x28, 0x0c, 0x52, 0x05, 0x72, 0x65, 0x70, 0x6c, 0x79, 0x22, 0x3e, 0x0a, 0x0d, 0x42,
+	0x75, 0x74, 0x74, 0x6f, 0x6e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x2d, 0x0a, 0x06,
+	0x62, 0x75, 0x74, 0x74, 0x6f, 0x6e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x15, 0x2e, 0x6c,
+	0x65, 0x64, 0x67, 0x65, 0x72, 0x5f, 0x67, 0x6f, 0x2e, 0x5a, 0x65, 0x6d, 0x75, 0x42, 0x75, 0x74,
+	0x74, 0x6f, 0x6e, 0x52, 0x06, 0x62, 0x75, 0x74, 0x74, 0x6f, 0x6e, 0x22, 0x4e, 0x0a, 0x13, 0x43,
+	0x6c, 0x69, 0x63, 0x6b, 0x54, 0x68, 0x72, 0x6f, 0x75, 0x67, 0x68, 0x52, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x12, 0x16, 0x0a, 0x06, 0x72, 0x65, 0x6a, 0x65, 0x63, 0x74, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x08, 0x52, 0x06, 0x72, 0x65, 0x6a, 0x65, 0x63, 0x74, 0x12, 0x1f, 0x0a, 0x0b, 0x6d, 0x61,
+	0x78, 0x5f, 0x73, 0x63, 0x72, 0x65, 0x65, 0x6e, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0d, 0x52,
+	0x0a, 0x6d, 0x61, 0x78, 0x53, 0x63, 0x72, 0x65, 0x65, 0x6e, 0x73, 0x22, 0x26, 0x0a, 0x0c, 0x43,
+	0x6f, 0x6e, 0x74, 0x72, 0x6f, 0x6c, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x12, 0x16, 0x0a, 0x06, 0x73,
+	0x63, 0x72, 0x65, 0x65, 0x6e, 0x18, 0x01, 0x20, 0x03, 0x28, 0x09, 0x52, 0x06, 0x73, 0x63, 0x72,
+	0x65, 0x65, 0x6e, 0x22, 0x11, 0x0a, 0x0f, 0x53, 0x6e, 0x61, 0x70, 0x73, 0x68, 0x6f, 0x74, 0x52,
+	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x22, 0x3d, 0x0a, 0x0d, 0x53, 0x6e, 0x61, 0x70, 0x73, 0x68,
+	0x6f, 0x74, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x69, 0x6d, 0x61, 0x67, 0x65,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x05, 0x69, 0x6d, 0x61, 0x67, 0x65, 0x12, 0x16, 0x0a,
+	0x06, 0x73, 0x63, 0x72, 0x65, 0x65, 0x6e, 0x18, 0x02, 0x20, 0x03, 0x28, 0x09, 0x52, 0x06, 0x73,
+	0x63, 0x72, 0x65, 0x65, 0x6e, 0x22, 0x47, 0x0a, 0x12, 0x57, 0x61, 0x69, 0x74, 0x46, 0x6f, 0x72,
+	0x54, 0x65, 0x78, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x74,
+	0x65, 0x78, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x74, 0x65, 0x78, 0x74, 0x12,
+	0x1d, 0x0a, 0x0a, 0x74, 0x69, 0x6d, 0x65, 0x6f, 0x75, 0x74, 0x5f, 0x6d, 0x73, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x0d, 0x52, 0x09, 0x74, 0x69, 0x6d, 0x65, 0x6f, 0x75, 0x74, 0x4d, 0x73, 0x22, 0x40,
+	0x0a, 0x10, 0x57, 0x61, 0x69, 0x74, 0x46, 0x6f, 0x72, 0x54, 0x65, 0x78, 0x74, 0x52, 0x65, 0x70,
+	0x6c, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x66, 0x6f, 0x75, 0x6e, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x08, 0x52, 0x05, 0x66, 0x6f, 0x75, 0x6e, 0x64, 0x12, 0x16, 0x0a, 0x06, 0x73, 0x63, 0x72, 0x65,
+	0x65, 0x6e, 0x18, 0x02, 0x20, 0x03, 0x28, 0x09, 0x52, 0x06, 0x73, 0x63, 0x72, 0x65, 0x65, 0x6e,
+	0x22, 0x0e, 0x0a, 0x0c, 0x52, 0x65, 0x73, 0x65, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
+	0x2a, 0x51, 0x0a, 0x0a, 0x5a, 0x65, 0x6d, 0x75, 0x42, 0x75, 0x74, 0x74, 0x6f, 0x6e, 0x12, 0x0f,
+	0x0a, 0x0b, 0x42, 0x55, 0x54, 0x54, 0x4f, 0x4e, 0x5f, 0x4e, 0x4f, 0x4e, 0x45, 0x10, 0x00, 0x12,
+	0x0f, 0x0a, 0x0b, 0x42, 0x55, 0x54, 0x54, 0x4f, 0x4e, 0x5f, 0x4c, 0x45, 0x46, 0x54, 0x10, 0x01,
+	0x12, 0x10, 0x0a, 0x0c, 0x42, 0x55, 0x54, 0x54, 0x4f, 0x4e, 0x5f, 0x52, 0x49, 0x47, 0x48, 0x54,
+	0x10, 0x02, 0x12, 0x0f, 0x0a, 0x0b, 0x42, 0x55, 0x54, 0x54, 0x4f, 0x4e, 0x5f, 0x42, 0x4f, 0x54,
+	0x48, 0x10, 0x03, 0x32, 0xae, 0x03, 0x0a, 0x0b, 0x5a, 0x65, 0x6d, 0x75, 0x43, 0x6f, 0x6d, 0x6d,
+	0x61, 0x6e, 0x64, 0x12, 0x42, 0x0a, 0x08, 0x45, 0x78, 0x63, 0x68, 0x61, 0x6e, 0x67, 0x65, 0x12,
+	0x1a, 0x2e, 0x6c, 0x65, 0x64, 0x67, 0x65, 0x72, 0x5f, 0x67, 0x6f, 0x2e, 0x45, 0x78, 0x63, 0x68,
+	0x61, 0x6e, 0x67, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x18, 0x2e, 0x6c, 0x65,
+	0x64, 0x67, 0x65, 0x72, 0x5f, 0x67, 0x6f, 0x2e, 0x45, 0x78, 0x63, 0x68, 0x61, 0x6e, 0x67, 0x65,
+	0x52, 0x65, 0x70, 0x6c, 0x79, 0x22, 0x00, 0x12, 0x42, 0x0a, 0x0b, 0x50, 0x72, 0x65, 0x73, 0x73,
+	0x42, 0x75, 0x74, 0x74, 0x6f, 0x6e, 0x12, 0x18, 0x2e, 0x6c, 0x65, 0x64, 0x67, 0x65, 0x72, 0x5f,
+	0x67, 0x6f, 0x2e, 0x42, 0x75, 0x74, 0x74, 0x6f, 0x6e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
+	0x1a, 0x17, 0x2e, 0x6c, 0x65, 0x64, 0x67, 0x65, 0x72, 0x5f, 0x67, 0x6f, 0x2e, 0x43, 0x6f, 0x6e,
+	0x74, 0x72, 0x6f, 0x6c, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x22, 0x00, 0x12, 0x49, 0x0a, 0x0c, 0x43,
+	0x6c, 0x69, 0x63, 0x6b, 0x54, 0x68, 0x72, 0x6f, 0x75, 0x67, 0x68, 0x12, 0x1e, 0x2e, 0x6c, 0x65,
+	0x64, 0x67, 0x65, 0x72, 0x5f, 0x67, 0x6f, 0x2e, 0x43, 0x6c, 0x69, 0x63, 0x6b, 0x54, 0x68, 0x72,
+	0x6f, 0x75, 0x67, 0x68, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x17, 0x2e, 0x6c, 0x65,
+	0x64, 0x67, 0x65, 0x72, 0x5f, 0x67, 0x6f, 0x2e, 0x43, 0x6f, 0x6e, 0x74, 0x72, 0x6f, 0x6c, 0x52,
+	0x65, 0x70, 0x6c, 0x79, 0x22, 0x00, 0x12, 0x42, 0x0a, 0x08, 0x53, 0x6e, 0x61, 0x70, 0x73, 0x68,
+	0x6f, 0x74, 0x12, 0x1a, 0x2e, 0x6c, 0x65, 0x64, 0x67, 0x65, 0x72, 0x5f, 0x67, 0x6f, 0x2e, 0x53,
+	0x6e, 0x61, 0x70, 0x73, 0x68, 0x6f, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x18,
+	0x2e, 0x6c, 0x65, 0x64, 0x67, 0x65, 0x72, 0x5f, 0x67, 0x6f, 0x2e, 0x53, 0x6e, 0x61, 0x70, 0x73,
+	0x68, 0x6f, 0x74, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x22, 0x00, 0x12, 0x4b, 0x0a, 0x0b, 0x57, 0x61,
+	0x69, 0x74, 0x46, 0x6f, 0x72, 0x54, 0x65, 0x78, 0x74, 0x12, 0x1d, 0x2e, 0x6c, 0x65, 0x64, 0x67,
+	0x65, 0x72, 0x5f, 0x67, 0x6f, 0x2e, 0x57, 0x61, 0x69, 0x74, 0x46, 0x6f, 0x72, 0x54, 0x65, 0x78,
+	0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1b, 0x2e, 0x6c, 0x65, 0x64, 0x67, 0x65,
+	0x72, 0x5f, 0x67, 0x6f, 0x2e, 0x57, 0x61, 0x69, 0x74, 0x46, 0x6f, 0x72, 0x54, 0x65, 0x78, 0x74,
+	0x52, 0x65, 0x70, 0x6c, 0x79, 0x22, 0x00, 0x12, 0x3b, 0x0a, 0x05, 0x52, 0x65, 0x73, 0x65, 0x74,
+	0x12, 0x17, 0x2e, 0x6c, 0x65, 0x64, 0x67, 0x65, 0x72, 0x5f, 0x67, 0x6f, 0x2e, 0x52, 0x65, 0x73,
+	0x65, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x17, 0x2e, 0x6c, 0x65, 0x64, 0x67,
+	0x65, 0x72, 0x5f, 0x67, 0x6f, 0x2e, 0x43, 0x6f, 0x6e, 0x74, 0x72, 0x6f, 0x6c, 0x52, 0x65, 0x70,
+	0x6c, 0x79, 0x22, 0x00, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
 }
 
 var (
@@ -153,19 +643,40 @@ func file_zemu_proto_rawDescGZIP() []byte {
 	return file_zemu_proto_rawDescData
 }
 
-var file_zemu_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
-var file_zemu_proto_goTypes = []interface{}{
-	(*ExchangeRequest)(nil), // 0: ledger_go.ExchangeRequest
-	(*ExchangeReply)(nil),   // 1: ledger_go.ExchangeReply
+var file_zemu_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_zemu_proto_msgTypes = make([]protoimpl.MessageInfo, 10)
+var file_zemu_proto_goTypes = []any{
+	(ZemuButton)(0),             // 0: ledger_go.ZemuButton
+	(*ExchangeRequest)(nil),     // 1: ledger_go.ExchangeRequest
+	(*ExchangeReply)(nil),       // 2: ledger_go.ExchangeReply
+	(*ButtonRequest)(nil),       // 3: ledger_go.ButtonRequest
+	(*ClickThroughRequest)(nil), // 4: ledger_go.ClickThroughRequest
+	(*ControlReply)(nil),        // 5: ledger_go.ControlReply
+	(*SnapshotRequest)(nil),     // 6: ledger_go.SnapshotRequest
+	(*SnapshotReply)(nil),       // 7: ledger_go.SnapshotReply
+	(*WaitForTextRequest)(nil),  // 8: ledger_go.WaitForTextRequest
+	(*WaitForTextReply)(nil),    // 9: ledger_go.WaitForTextReply
+	(*ResetRequest)(nil),        // 10: ledger_go.ResetRequest
 }
 var file_zemu_proto_depIdxs = []int32{
-	0, // 0: ledger_go.ZemuCommand.Exchange:input_type -> ledger_go.ExchangeRequest
-	1, // 1: ledger_go.ZemuCommand.Exchange:output_type -> ledger_go.ExchangeReply
-	1, // [1:2] is the sub-list for method output_type
-	0, // [0:1] is the sub-list for method input_type
-	0, // [0:0] is the sub-list for extension type_name
-	0, // [0:0] is the sub-list for extension extendee
-	0, // [0:0] is the sub-list for field type_name
+	0,  // 0: ledger_go.ButtonRequest.button:type_name -> ledger_go.ZemuButton
+	1,  // 1: ledger_go.ZemuCommand.Exchange:input_type -> ledger_go.ExchangeRequest
+	3,  // 2: ledger_go.ZemuCommand.PressButton:input_type -> ledger_go.ButtonRequest
+	4,  // 3: ledger_go.ZemuCommand.ClickThrough:input_type -> ledger_go.ClickThroughRequest
+	6,  // 4: ledger_go.ZemuCommand.Snapshot:input_type -> ledger_go.SnapshotRequest
+	8,  // 5: ledger_go.ZemuCommand.WaitForText:input_type -> ledger_go.WaitForTextRequest
+	10, // 6: ledger_go.ZemuCommand.Reset:input_type -> ledger_go.ResetRequest
+	2,  // 7: ledger_go.ZemuCommand.Exchange:output_type -> ledger_go.ExchangeReply
+	5,  // 8: ledger_go.ZemuCommand.PressButton:output_type -> ledger_go.ControlReply
+	5,  // 9: ledger_go.ZemuCommand.ClickThrough:output_type -> ledger_go.ControlReply
+	7,  // 10: ledger_go.ZemuCommand.Snapshot:output_type -> ledger_go.SnapshotReply
+	9,  // 11: ledger_go.ZemuCommand.WaitForText:output_type -> ledger_go.WaitForTextReply
+	5,  // 12: ledger_go.ZemuCommand.Reset:output_type -> ledger_go.ControlReply
+	7,  // [7:13] is the sub-list for method output_type
+	1,  // [1:7] is the sub-list for method input_type
+	1,  // [1:1] is the sub-list for extension type_name
+	1,  // [1:1] is the sub-list for extension extendee
+	0,  // [0:1] is the sub-list for field type_name
 }
 
 func init() { file_zemu_proto_init() }
@@ -174,7 +685,7 @@ func file_zemu_proto_init() {
 		return
 	}
 	if !protoimpl.UnsafeEnabled {
-		file_zemu_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+		file_zemu_proto_msgTypes[0].Exporter = func(v any, i int) any {
 			switch v := v.(*ExchangeRequest); i {
 			case 0:
 				return &v.state
@@ -186,7 +697,7 @@ func file_zemu_proto_init() {
 				return nil
 			}
 		}
-		file_zemu_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+		file_zemu_proto_msgTypes[1].Exporter = func(v any, i int) any {
 			switch v := v.(*ExchangeReply); i {
 			case 0:
 				return &v.state
@@ -198,19 +709,116 @@ func file_zemu_proto_init() {
 				return nil
 			}
 		}
+		file_zemu_proto_msgTypes[2].Exporter = func(v any, i int) any {
+			switch v := v.(*ButtonRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_zemu_proto_msgTypes[3].Exporter = func(v any, i int) any {
+			switch v := v.(*ClickThroughRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_zemu_proto_msgTypes[4].Exporter = func(v any, i int) any {
+			switch v := v.(*ControlReply); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_zemu_proto_msgTypes[5].Exporter = func(v any, i int) any {
+			switch v := v.(*SnapshotRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_zemu_proto_msgTypes[6].Exporter = func(v any, i int) any {
+			switch v := v.(*SnapshotReply); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_zemu_proto_msgTypes[7].Exporter = func(v any, i int) any {
+			switch v := v.(*WaitForTextRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_zemu_proto_msgTypes[8].Exporter = func(v any, i int) any {
+			switch v := v.(*WaitForTextReply); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_zemu_proto_msgTypes[9].Exporter = func(v any, i int) any {
+			switch v := v.(*ResetRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
 	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_zemu_proto_rawDesc,
-			NumEnums:      0,
-			NumMessages:   2,
+			NumEnums:      1,
+			NumMessages:   10,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
 		GoTypes:           file_zemu_proto_goTypes,
 		DependencyIndexes: file_zemu_proto_depIdxs,
+		EnumInfos:         file_zemu_proto_enumTypes,
 		MessageInfos:      file_zemu_proto_msgTypes,
 	}.Build()
 	File_zemu_proto = out.File
@@ -218,83 +826,3 @@ func file_zemu_proto_init() {
 	file_zemu_proto_goTypes = nil
 	file_zemu_proto_depIdxs = nil
 }
-
-// Reference imports to suppress errors if they are not otherwise used.
-var _ context.Context
-var _ grpc.ClientConnInterface
-
-// This is a compile-time assertion to ensure that this generated file
-// is compatible with the grpc package it is being compiled against.
-const _ = grpc.SupportPackageIsVersion6
-
-// ZemuCommandClient is the client API for ZemuCommand service.
-//
-// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
-type ZemuCommandClient interface {
-	Exchange(ctx context.Context, in *ExchangeRequest, opts ...grpc.CallOption) (*ExchangeReply, error)
-}
-
-type zemuCommandClient struct {
-	cc grpc.ClientConnInterface
-}
-
-func NewZemuCommandClient(cc grpc.ClientConnInterface) ZemuCommandClient {
-	return &zemuCommandClient{cc}
-}
-
-func (c *zemuCommandClient) Exchange(ctx context.Context, in *ExchangeRequest, opts ...grpc.CallOption) (*ExchangeReply, error) {
-	out := new(ExchangeReply)
-	err := c.cc.Invoke(ctx, "/ledger_go.ZemuCommand/Exchange", in, out, opts...)
-	if err != nil {
-		return nil, err
-	}
-	return out, nil
-}
-
-// ZemuCommandServer is the server API for ZemuCommand service.
-type ZemuCommandServer interface {
-	Exchange(context.Context, *ExchangeRequest) (*ExchangeReply, error)
-}
-
-// UnimplementedZemuCommandServer can be embedded to have forward compatible implementations.
-type UnimplementedZemuCommandServer struct {
-}
-
-func (*UnimplementedZemuCommandServer) Exchange(context.Context, *ExchangeRequest) (*ExchangeReply, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method Exchange not implemented")
-}
-
-func RegisterZemuCommandServer(s *grpc.Server, srv ZemuCommandServer) {
-	s.RegisterService(&_ZemuCommand_serviceDesc, srv)
-}
-
-func _ZemuCommand_Exchange_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(ExchangeRequest)
-	if err := dec(in); err != nil {
-		return nil, err
-	}
-	if interceptor == nil {
-		return srv.(ZemuCommandServer).Exchange(ctx, in)
-	}
-	info := &grpc.UnaryServerInfo{
-		Server:     srv,
-		FullMethod: "/ledger_go.ZemuCommand/Exchange",
-	}
-	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(ZemuCommandServer).Exchange(ctx, req.(*ExchangeRequest))
-	}
-	return interceptor(ctx, in, info, handler)
-}
-
-var _ZemuCommand_serviceDesc = grpc.ServiceDesc{
-	ServiceName: "ledger_go.ZemuCommand",
-	HandlerType: (*ZemuCommandServer)(nil),
-	Methods: []grpc.MethodDesc{
-		{
-			MethodName: "Exchange",
-			Handler:    _ZemuCommand_Exchange_Handler,
-		},
-	},
-	Streams:  []grpc.StreamDesc{},
-	Metadata: "zemu.proto",
-}
@@ -0,0 +1,286 @@
+/*******************************************************************************
+*   (c) Zondax AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+********************************************************************************/
+
+package ledger_go
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"fmt"
+	"image"
+	"image/png"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	ZemuScreenApprove = "Approve"
+	ZemuScreenReject  = "Reject"
+
+	zemuScreenWidth  = 128
+	zemuScreenHeight = 64
+
+	// defaultClickThroughScreens bounds ClickThrough when the request sets no limit
+	defaultClickThroughScreens = 32
+	// zemuReviewWait is how long ClickThrough waits for a request to review
+	zemuReviewWait = 5 * time.Second
+	// zemuDataPreview is the number of data bytes shown on the review screens
+	zemuDataPreview = 16
+)
+
+// ZemuReviewScreens returns the screens showing command for review, one slice of lines per screen.
+// The Approve and Reject screens are added after them.
+type ZemuReviewScreens func(command *Command) [][]string
+
+// ZemuEmulator is a ZemuCommandServer standing in for the Zemu emulator, so that clients can be
+// tested offline. Exchanges are answered by a VirtualDevice, whose prompts are shown as review
+// screens navigated with the buttons: right and left move between screens, and both buttons
+// pressed together on the Approve or Reject screen answer the prompt.
+type ZemuEmulator struct {
+	UnimplementedZemuCommandServer
+
+	device *VirtualDevice
+
+	// Home is the screen shown when nothing is under review
+	Home []string
+	// Review builds the review screens, a summary of the command is shown when nil
+	Review ZemuReviewScreens
+
+	mu      sync.Mutex
+	review  *zemuReview
+	changed chan struct{}
+}
+
+// zemuReview is a prompt under review
+type zemuReview struct {
+	screens  [][]string
+	index    int
+	decision chan bool
+}
+
+// NewZemuEmulator serves device, taking over its prompts. Home and Review are set before serving.
+func NewZemuEmulator(device *VirtualDevice) *ZemuEmulator {
+	emulator := &ZemuEmulator{
+		device:  device,
+		Home:    []string{"Application", "is ready"},
+		changed: make(chan struct{}),
+	}
+	device.SetPrompter(emulator.prompt)
+	return emulator
+}
+
+// Screen returns the text currently on screen.
+func (e *ZemuEmulator) Screen() []string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.screenLocked()
+}
+
+func (e *ZemuEmulator) screenLocked() []string {
+	if e.review == nil {
+		return append([]string{}, e.Home...)
+	}
+	return append([]string{}, e.review.screens[e.review.index]...)
+}
+
+// notifyLocked wakes up the calls waiting for the screen to change
+func (e *ZemuEmulator) notifyLocked() {
+	close(e.changed)
+	e.changed = make(chan struct{})
+}
+
+func (e *ZemuEmulator) prompt(command *Command, cancel <-chan struct{}) bool {
+	screens := defaultReviewScreens(command)
+	if e.Review != nil {
+		screens = e.Review(command)
+	}
+	screens = append(screens, []string{ZemuScreenApprove}, []string{ZemuScreenReject})
+
+	review := &zemuReview{screens: screens, decision: make(chan bool, 1)}
+	e.mu.Lock()
+	e.review = review
+	e.notifyLocked()
+	e.mu.Unlock()
+
+	select {
+	case approved := <-review.decision:
+		return approved
+	case <-cancel:
+		e.mu.Lock()
+		e.decideLocked(false)
+		e.mu.Unlock()
+		return false
+	}
+}
+
+func defaultReviewScreens(command *Command) [][]string {
+	data := command.Data
+	if len(data) > zemuDataPreview {
+		data = data[:zemuDataPreview]
+	}
+	return [][]string{
+		{"Review", "request"},
+		{fmt.Sprintf("CLA %02x INS %02x", command.CLA, command.INS), fmt.Sprintf("P1 %02x P2 %02x", command.P1, command.P2)},
+		{fmt.Sprintf("Data (%d bytes)", len(command.Data)), hex.EncodeToString(data)},
+	}
+}
+
+// decideLocked answers the prompt under review, if any, and goes back to the home screen
+func (e *ZemuEmulator) decideLocked(approved bool) {
+	if e.review == nil {
+		return
+	}
+	e.review.decision <- approved
+	e.review = nil
+	e.notifyLocked()
+}
+
+// press handles a button and returns the screen afterwards
+func (e *ZemuEmulator) press(button ZemuButton) ([]string, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	review := e.review
+	switch button {
+	case ZemuButton_BUTTON_LEFT:
+		if review != nil && review.index > 0 {
+			review.index--
+			e.notifyLocked()
+		}
+	case ZemuButton_BUTTON_RIGHT:
+		if review != nil && review.index < len(review.screens)-1 {
+			review.index++
+			e.notifyLocked()
+		}
+	case ZemuButton_BUTTON_BOTH:
+		if review != nil && review.index >= len(review.screens)-2 {
+			e.decideLocked(review.index == len(review.screens)-2)
+		}
+	default:
+		return nil, fmt.Errorf("unknown button %s", button)
+	}
+	return e.screenLocked(), nil
+}
+
+// waitFor waits until condition holds or ctx is done. condition is called with the lock held.
+func (e *ZemuEmulator) waitFor(ctx context.Context, condition func() bool) bool {
+	for {
+		e.mu.Lock()
+		ok := condition()
+		changed := e.changed
+		e.mu.Unlock()
+
+		if ok {
+			return true
+		}
+
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return false
+		}
+	}
+}
+
+func (e *ZemuEmulator) Exchange(_ context.Context, request *ExchangeRequest) (*ExchangeReply, error) {
+	return &ExchangeReply{Reply: e.device.Process(request.Command)}, nil
+}
+
+func (e *ZemuEmulator) PressButton(_ context.Context, request *ButtonRequest) (*ControlReply, error) {
+	screen, err := e.press(request.Button)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	return &ControlReply{Screen: screen}, nil
+}
+
+// ClickThrough waits for a request to review, moves right to the Approve or Reject screen and
+// presses both buttons.
+func (e *ZemuEmulator) ClickThrough(ctx context.Context, request *ClickThroughRequest) (*ControlReply, error) {
+	ctx, cancel := context.WithTimeout(ctx, zemuReviewWait)
+	defer cancel()
+
+	if !e.waitFor(ctx, func() bool { return e.review != nil }) {
+		return nil, status.Error(codes.FailedPrecondition, "no request to review")
+	}
+
+	maxScreens := int(request.MaxScreens)
+	if maxScreens == 0 {
+		maxScreens = defaultClickThroughScreens
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	review := e.review
+	if review == nil {
+		return nil, status.Error(codes.FailedPrecondition, "no request to review")
+	}
+
+	target := len(review.screens) - 2
+	if request.Reject {
+		target++
+	}
+	if target-review.index > maxScreens {
+		return nil, status.Errorf(codes.FailedPrecondition, "review has more than %d screens", maxScreens)
+	}
+
+	review.index = target
+	e.decideLocked(!request.Reject)
+	return &ControlReply{Screen: e.screenLocked()}, nil
+}
+
+// Snapshot returns the screen text along with a blank image of the screen size.
+func (e *ZemuEmulator) Snapshot(_ context.Context, _ *SnapshotRequest) (*SnapshotReply, error) {
+	var buffer bytes.Buffer
+	if err := png.Encode(&buffer, image.NewGray(image.Rect(0, 0, zemuScreenWidth, zemuScreenHeight))); err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return &SnapshotReply{Image: buffer.Bytes(), Screen: e.Screen()}, nil
+}
+
+// WaitForText waits until a line on screen contains the text, for up to the request timeout.
+func (e *ZemuEmulator) WaitForText(ctx context.Context, request *WaitForTextRequest) (*WaitForTextReply, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(request.TimeoutMs)*time.Millisecond)
+	defer cancel()
+
+	var screen []string
+	found := e.waitFor(ctx, func() bool {
+		screen = e.screenLocked()
+		for _, line := range screen {
+			if strings.Contains(line, request.Text) {
+				return true
+			}
+		}
+		return false
+	})
+
+	return &WaitForTextReply{Found: found, Screen: screen}, nil
+}
+
+// Reset rejects the request under review and goes back to the home screen.
+func (e *ZemuEmulator) Reset(_ context.Context, _ *ResetRequest) (*ControlReply, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.decideLocked(false)
+	return &ControlReply{Screen: e.screenLocked()}, nil
+}
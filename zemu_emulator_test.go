@@ -0,0 +1,170 @@
+/*******************************************************************************
+*   (c) Zondax AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+********************************************************************************/
+
+package ledger_go
+
+import (
+	"bytes"
+	"context"
+	"image/png"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const insConfirm = 0x20
+
+// newEmulatedApp returns an emulator for an app replying 0x01 once a confirmation is approved
+func newEmulatedApp() *ZemuEmulator {
+	device := newVirtualApp()
+	device.Handle(virtualCLA, insConfirm, func(command *Command) ([]byte, uint16) {
+		if !device.Prompt(command) {
+			return nil, SwCommandNotAllowed
+		}
+		return []byte{0x01}, SwOK
+	})
+	return NewZemuEmulator(device)
+}
+
+// exchangeAsync sends a confirmation to emulator and returns the channel receiving the raw reply
+func exchangeAsync(emulator *ZemuEmulator) <-chan []byte {
+	reply := make(chan []byte, 1)
+	go func() {
+		response, _ := emulator.Exchange(context.Background(), &ExchangeRequest{Command: []byte{virtualCLA, insConfirm, 0x00, 0x00, 0x01, 0xaa}})
+		reply <- response.GetReply()
+	}()
+	return reply
+}
+
+func waitForText(t *testing.T, emulator *ZemuEmulator, text string) {
+	reply, err := emulator.WaitForText(context.Background(), &WaitForTextRequest{Text: text, TimeoutMs: 2000})
+	require.NoError(t, err)
+	require.True(t, reply.Found, "%q not found on %v", text, reply.Screen)
+}
+
+func TestZemuEmulatorButtons(t *testing.T) {
+	emulator := newEmulatedApp()
+	ctx := context.Background()
+	assert.Equal(t, emulator.Home, emulator.Screen())
+
+	reply := exchangeAsync(emulator)
+	waitForText(t, emulator, "Review")
+
+	// Both buttons only answer on the Approve and Reject screens
+	control, err := emulator.PressButton(ctx, &ButtonRequest{Button: ZemuButton_BUTTON_BOTH})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Review", "request"}, control.Screen)
+
+	control, err = emulator.PressButton(ctx, &ButtonRequest{Button: ZemuButton_BUTTON_LEFT})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Review", "request"}, control.Screen)
+
+	control, err = emulator.PressButton(ctx, &ButtonRequest{Button: ZemuButton_BUTTON_RIGHT})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"CLA 55 INS 20", "P1 00 P2 00"}, control.Screen)
+
+	for i := 0; i < 5; i++ {
+		control, err = emulator.PressButton(ctx, &ButtonRequest{Button: ZemuButton_BUTTON_RIGHT})
+		require.NoError(t, err)
+	}
+	assert.Equal(t, []string{ZemuScreenReject}, control.Screen)
+
+	control, err = emulator.PressButton(ctx, &ButtonRequest{Button: ZemuButton_BUTTON_LEFT})
+	require.NoError(t, err)
+	assert.Equal(t, []string{ZemuScreenApprove}, control.Screen)
+
+	control, err = emulator.PressButton(ctx, &ButtonRequest{Button: ZemuButton_BUTTON_BOTH})
+	require.NoError(t, err)
+	assert.Equal(t, emulator.Home, control.Screen)
+	assert.Equal(t, []byte{0x01, 0x90, 0x00}, <-reply)
+
+	_, err = emulator.PressButton(ctx, &ButtonRequest{Button: ZemuButton_BUTTON_NONE})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestZemuEmulatorClickThrough(t *testing.T) {
+	emulator := newEmulatedApp()
+	ctx := context.Background()
+
+	reply := exchangeAsync(emulator)
+	_, err := emulator.ClickThrough(ctx, &ClickThroughRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0x01, 0x90, 0x00}, <-reply)
+
+	reply = exchangeAsync(emulator)
+	_, err = emulator.ClickThrough(ctx, &ClickThroughRequest{Reject: true})
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0x69, 0x86}, <-reply)
+
+	// The review does not fit in the screen limit and is left pending
+	reply = exchangeAsync(emulator)
+	_, err = emulator.ClickThrough(ctx, &ClickThroughRequest{MaxScreens: 2})
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+
+	_, err = emulator.Reset(ctx, &ResetRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0x69, 0x86}, <-reply)
+
+	ctx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	_, err = emulator.ClickThrough(ctx, &ClickThroughRequest{})
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+}
+
+func TestZemuEmulatorCustomReview(t *testing.T) {
+	emulator := newEmulatedApp()
+	emulator.Review = func(command *Command) [][]string {
+		return [][]string{{"Sign", "transaction"}}
+	}
+
+	reply := exchangeAsync(emulator)
+	waitForText(t, emulator, "transaction")
+
+	snapshot, err := emulator.Snapshot(context.Background(), &SnapshotRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Sign", "transaction"}, snapshot.Screen)
+	config, err := png.DecodeConfig(bytes.NewReader(snapshot.Image))
+	require.NoError(t, err)
+	assert.Equal(t, zemuScreenWidth, config.Width)
+
+	_, err = emulator.ClickThrough(context.Background(), &ClickThroughRequest{MaxScreens: 1})
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0x01, 0x90, 0x00}, <-reply)
+}
+
+func TestZemuEmulatorWaitForTextTimeout(t *testing.T) {
+	emulator := newEmulatedApp()
+
+	reply, err := emulator.WaitForText(context.Background(), &WaitForTextRequest{Text: "Review", TimeoutMs: 20})
+	require.NoError(t, err)
+	assert.False(t, reply.Found)
+	assert.Equal(t, emulator.Home, reply.Screen)
+}
+
+func TestZemuEmulatorDeviceClosed(t *testing.T) {
+	emulator := newEmulatedApp()
+
+	reply := exchangeAsync(emulator)
+	waitForText(t, emulator, "Review")
+
+	require.NoError(t, emulator.device.Close())
+	assert.Equal(t, []byte{0x69, 0x86}, <-reply)
+	assert.Equal(t, emulator.Home, emulator.Screen())
+}
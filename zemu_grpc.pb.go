@@ -0,0 +1,313 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: zemu.proto
+
+package ledger_go
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	ZemuCommand_Exchange_FullMethodName     = "/ledger_go.ZemuCommand/Exchange"
+	ZemuCommand_PressButton_FullMethodName  = "/ledger_go.ZemuCommand/PressButton"
+	ZemuCommand_ClickThrough_FullMethodName = "/ledger_go.ZemuCommand/ClickThrough"
+	ZemuCommand_Snapshot_FullMethodName     = "/ledger_go.ZemuCommand/Snapshot"
+	ZemuCommand_WaitForText_FullMethodName  = "/ledger_go.ZemuCommand/WaitForText"
+	ZemuCommand_Reset_FullMethodName        = "/ledger_go.ZemuCommand/Reset"
+)
+
+// ZemuCommandClient is the client API for ZemuCommand service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type ZemuCommandClient interface {
+	Exchange(ctx context.Context, in *ExchangeRequest, opts ...grpc.CallOption) (*ExchangeReply, error)
+	// Emulator control
+	PressButton(ctx context.Context, in *ButtonRequest, opts ...grpc.CallOption) (*ControlReply, error)
+	ClickThrough(ctx context.Context, in *ClickThroughRequest, opts ...grpc.CallOption) (*ControlReply, error)
+	Snapshot(ctx context.Context, in *SnapshotRequest, opts ...grpc.CallOption) (*SnapshotReply, error)
+	WaitForText(ctx context.Context, in *WaitForTextRequest, opts ...grpc.CallOption) (*WaitForTextReply, error)
+	Reset(ctx context.Context, in *ResetRequest, opts ...grpc.CallOption) (*ControlReply, error)
+}
+
+type zemuCommandClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewZemuCommandClient(cc grpc.ClientConnInterface) ZemuCommandClient {
+	return &zemuCommandClient{cc}
+}
+
+func (c *zemuCommandClient) Exchange(ctx context.Context, in *ExchangeRequest, opts ...grpc.CallOption) (*ExchangeReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ExchangeReply)
+	err := c.cc.Invoke(ctx, ZemuCommand_Exchange_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *zemuCommandClient) PressButton(ctx context.Context, in *ButtonRequest, opts ...grpc.CallOption) (*ControlReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ControlReply)
+	err := c.cc.Invoke(ctx, ZemuCommand_PressButton_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *zemuCommandClient) ClickThrough(ctx context.Context, in *ClickThroughRequest, opts ...grpc.CallOption) (*ControlReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ControlReply)
+	err := c.cc.Invoke(ctx, ZemuCommand_ClickThrough_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *zemuCommandClient) Snapshot(ctx context.Context, in *SnapshotRequest, opts ...grpc.CallOption) (*SnapshotReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SnapshotReply)
+	err := c.cc.Invoke(ctx, ZemuCommand_Snapshot_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *zemuCommandClient) WaitForText(ctx context.Context, in *WaitForTextRequest, opts ...grpc.CallOption) (*WaitForTextReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(WaitForTextReply)
+	err := c.cc.Invoke(ctx, ZemuCommand_WaitForText_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *zemuCommandClient) Reset(ctx context.Context, in *ResetRequest, opts ...grpc.CallOption) (*ControlReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ControlReply)
+	err := c.cc.Invoke(ctx, ZemuCommand_Reset_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ZemuCommandServer is the server API for ZemuCommand service.
+// All implementations must embed UnimplementedZemuCommandServer
+// for forward compatibility.
+type ZemuCommandServer interface {
+	Exchange(context.Context, *ExchangeRequest) (*ExchangeReply, error)
+	// Emulator control
+	PressButton(context.Context, *ButtonRequest) (*ControlReply, error)
+	ClickThrough(context.Context, *ClickThroughRequest) (*ControlReply, error)
+	Snapshot(context.Context, *SnapshotRequest) (*SnapshotReply, error)
+	WaitForText(context.Context, *WaitForTextRequest) (*WaitForTextReply, error)
+	Reset(context.Context, *ResetRequest) (*ControlReply, error)
+	mustEmbedUnimplementedZemuCommandServer()
+}
+
+// UnimplementedZemuCommandServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedZemuCommandServer struct{}
+
+func (UnimplementedZemuCommandServer) Exchange(context.Context, *ExchangeRequest) (*ExchangeReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Exchange not implemented")
+}
+func (UnimplementedZemuCommandServer) PressButton(context.Context, *ButtonRequest) (*ControlReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PressButton not implemented")
+}
+func (UnimplementedZemuCommandServer) ClickThrough(context.Context, *ClickThroughRequest) (*ControlReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ClickThrough not implemented")
+}
+func (UnimplementedZemuCommandServer) Snapshot(context.Context, *SnapshotRequest) (*SnapshotReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Snapshot not implemented")
+}
+func (UnimplementedZemuCommandServer) WaitForText(context.Context, *WaitForTextRequest) (*WaitForTextReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method WaitForText not implemented")
+}
+func (UnimplementedZemuCommandServer) Reset(context.Context, *ResetRequest) (*ControlReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Reset not implemented")
+}
+func (UnimplementedZemuCommandServer) mustEmbedUnimplementedZemuCommandServer() {}
+func (UnimplementedZemuCommandServer) testEmbeddedByValue()                     {}
+
+// UnsafeZemuCommandServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to ZemuCommandServer will
+// result in compilation errors.
+type UnsafeZemuCommandServer interface {
+	mustEmbedUnimplementedZemuCommandServer()
+}
+
+func RegisterZemuCommandServer(s grpc.ServiceRegistrar, srv ZemuCommandServer) {
+	// If the following call pancis, it indicates UnimplementedZemuCommandServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&ZemuCommand_ServiceDesc, srv)
+}
+
+func _ZemuCommand_Exchange_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ExchangeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ZemuCommandServer).Exchange(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ZemuCommand_Exchange_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ZemuCommandServer).Exchange(ctx, req.(*ExchangeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ZemuCommand_PressButton_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ButtonRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ZemuCommandServer).PressButton(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ZemuCommand_PressButton_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ZemuCommandServer).PressButton(ctx, req.(*ButtonRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ZemuCommand_ClickThrough_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ClickThroughRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ZemuCommandServer).ClickThrough(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ZemuCommand_ClickThrough_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ZemuCommandServer).ClickThrough(ctx, req.(*ClickThroughRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ZemuCommand_Snapshot_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SnapshotRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ZemuCommandServer).Snapshot(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ZemuCommand_Snapshot_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ZemuCommandServer).Snapshot(ctx, req.(*SnapshotRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ZemuCommand_WaitForText_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(WaitForTextRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ZemuCommandServer).WaitForText(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ZemuCommand_WaitForText_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ZemuCommandServer).WaitForText(ctx, req.(*WaitForTextRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ZemuCommand_Reset_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ResetRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ZemuCommandServer).Reset(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ZemuCommand_Reset_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ZemuCommandServer).Reset(ctx, req.(*ResetRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// ZemuCommand_ServiceDesc is the grpc.ServiceDesc for ZemuCommand service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var ZemuCommand_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "ledger_go.ZemuCommand",
+	HandlerType: (*ZemuCommandServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Exchange",
+			Handler:    _ZemuCommand_Exchange_Handler,
+		},
+		{
+			MethodName: "PressButton",
+			Handler:    _ZemuCommand_PressButton_Handler,
+		},
+		{
+			MethodName: "ClickThrough",
+			Handler:    _ZemuCommand_ClickThrough_Handler,
+		},
+		{
+			MethodName: "Snapshot",
+			Handler:    _ZemuCommand_Snapshot_Handler,
+		},
+		{
+			MethodName: "WaitForText",
+			Handler:    _ZemuCommand_WaitForText_Handler,
+		},
+		{
+			MethodName: "Reset",
+			Handler:    _ZemuCommand_Reset_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "zemu.proto",
+}
@@ -0,0 +1,141 @@
+/*******************************************************************************
+*   (c) Zondax AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+********************************************************************************/
+
+package ledger_go
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+// DefaultZemuServerAddress is where ZemuServer.Listen binds when no address is given.
+// Only the local host can reach it.
+const DefaultZemuServerAddress = "127.0.0.1:3002"
+
+const ErrMsgInsecureZemuServer = "unauthenticated Zemu server on a non loopback address"
+
+var ErrInsecureZemuServer = errors.New(ErrMsgInsecureZemuServer)
+
+// ZemuServer serves a LedgerDevice over the ZemuCommand service, so that clients without
+// access to the device, such as LedgerDeviceZemu in a container, can exchange with it.
+// Emulator control calls are not implemented.
+//
+// The service has no authentication: whoever reaches it can send any command to the
+// device, a real one included, and plaintext traffic can be read on the way. Listen
+// only binds loopback addresses unless WithZemuServerTLS requires client certificates.
+type ZemuServer struct {
+	UnimplementedZemuCommandServer
+
+	device    LedgerDevice
+	tlsConfig *tls.Config
+}
+
+// ZemuServerOption configures a ZemuServer.
+type ZemuServerOption func(*ZemuServer)
+
+// WithZemuServerTLS serves over TLS instead of plaintext. Setting ClientAuth in config to
+// tls.RequireAndVerifyClientCert restricts access to clients holding a trusted certificate,
+// which Listen requires for addresses other hosts can reach.
+func WithZemuServerTLS(config *tls.Config) ZemuServerOption {
+	return func(s *ZemuServer) {
+		s.tlsConfig = config
+	}
+}
+
+func NewZemuServer(device LedgerDevice, options ...ZemuServerOption) *ZemuServer {
+	s := &ZemuServer{device: device}
+	for _, option := range options {
+		option(s)
+	}
+	return s
+}
+
+// NewGRPCServer returns a grpc server with the ZemuCommand and health services registered,
+// using the TLS configuration of the server if any.
+func (s *ZemuServer) NewGRPCServer(options ...grpc.ServerOption) *grpc.Server {
+	if s.tlsConfig != nil {
+		options = append([]grpc.ServerOption{grpc.Creds(credentials.NewTLS(s.tlsConfig))}, options...)
+	}
+
+	server := grpc.NewServer(options...)
+	RegisterZemuCommandServer(server, s)
+	healthpb.RegisterHealthServer(server, health.NewServer())
+	return server
+}
+
+// Listen opens a TCP listener on address, DefaultZemuServerAddress when empty.
+// Addresses that other hosts could reach fail with ErrInsecureZemuServer unless clients
+// must present a verified certificate.
+func (s *ZemuServer) Listen(address string) (net.Listener, error) {
+	if address == "" {
+		address = DefaultZemuServerAddress
+	}
+
+	if !s.authenticatesClients() && !isLoopbackAddress(address) {
+		return nil, fmt.Errorf("%w: %q, use a loopback address or WithZemuServerTLS with tls.RequireAndVerifyClientCert",
+			ErrInsecureZemuServer, address)
+	}
+	return net.Listen("tcp", address)
+}
+
+func (s *ZemuServer) authenticatesClients() bool {
+	return s.tlsConfig != nil && s.tlsConfig.ClientAuth >= tls.RequireAndVerifyClientCert
+}
+
+func isLoopbackAddress(address string) bool {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// Exchange forwards the command to the device and returns its raw reply, status word included.
+// Failures without a status word are returned as grpc errors.
+func (s *ZemuServer) Exchange(_ context.Context, request *ExchangeRequest) (*ExchangeReply, error) {
+	response, err := s.device.Exchange(request.Command)
+
+	raw, ok := rawResponse(response, err)
+	if !ok {
+		return nil, status.Error(exchangeErrorCode(err), err.Error())
+	}
+	return &ExchangeReply{Reply: raw}, nil
+}
+
+func exchangeErrorCode(err error) codes.Code {
+	switch {
+	case errors.Is(err, ErrCommandTooShort), errors.Is(err, ErrCommandLengthMismatch):
+		return codes.InvalidArgument
+	case errors.Is(err, ErrDeviceClosed), errors.Is(err, ErrDisconnected):
+		return codes.Unavailable
+	default:
+		return codes.Internal
+	}
+}
@@ -0,0 +1,117 @@
+/*******************************************************************************
+*   (c) Zondax AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+********************************************************************************/
+
+package ledger_go
+
+import (
+	"context"
+	"crypto/tls"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+// serveZemu serves device on a free local port and returns a connection to it
+func serveZemu(t *testing.T, device LedgerDevice) *grpc.ClientConn {
+	zemuServer := NewZemuServer(device)
+	listener, err := zemuServer.Listen("127.0.0.1:0")
+	require.NoError(t, err)
+
+	server := zemuServer.NewGRPCServer()
+	go func() { _ = server.Serve(listener) }()
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient(listener.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+	return conn
+}
+
+func TestZemuServerExchange(t *testing.T) {
+	client := NewZemuCommandClient(serveZemu(t, newVirtualApp()))
+	ctx := context.Background()
+
+	reply, err := client.Exchange(ctx, &ExchangeRequest{Command: []byte{virtualCLA, InsGetVersion, 0x00, 0x00, 0x00}})
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0x00, 0x00, 0x01, 0x00, 0x02, 0x00, 0x03, 0x00, 0x33, 0x00, 0x00, 0x04, 0x90, 0x00}, reply.Reply)
+
+	// Status words are part of the reply
+	reply, err = client.Exchange(ctx, &ExchangeRequest{Command: []byte{virtualCLA, 0x09, 0x00, 0x00, 0x00}})
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0x6d, 0x00}, reply.Reply)
+
+	_, err = client.Exchange(ctx, &ExchangeRequest{Command: []byte{virtualCLA, 0x09}})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, err = client.PressButton(ctx, &ButtonRequest{Button: ZemuButton_BUTTON_BOTH})
+	assert.Equal(t, codes.Unimplemented, status.Code(err))
+}
+
+func TestZemuServerDeviceFailures(t *testing.T) {
+	ctx := context.Background()
+	command := &ExchangeRequest{Command: []byte{virtualCLA, InsGetVersion, 0x00, 0x00, 0x00}}
+
+	device := newVirtualApp()
+	client := NewZemuCommandClient(serveZemu(t, device))
+	require.NoError(t, device.Close())
+	_, err := client.Exchange(ctx, command)
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+
+	faulty := NewFaultyDevice(newVirtualApp(), FaultConfig{DisconnectAfter: 1})
+	client = NewZemuCommandClient(serveZemu(t, faulty))
+	_, err = client.Exchange(ctx, command)
+	require.NoError(t, err)
+	_, err = client.Exchange(ctx, command)
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+	assert.Contains(t, status.Convert(err).Message(), ErrMsgDisconnected)
+}
+
+func TestZemuServerHealth(t *testing.T) {
+	response, err := healthpb.NewHealthClient(serveZemu(t, newVirtualApp())).Check(context.Background(), &healthpb.HealthCheckRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, response.Status)
+}
+
+func TestZemuServerListen(t *testing.T) {
+	for _, address := range []string{"127.0.0.1:3002", "127.1.2.3:0", "[::1]:0", "localhost:0"} {
+		assert.True(t, isLoopbackAddress(address), address)
+	}
+
+	server := NewZemuServer(newVirtualApp())
+	listener, err := server.Listen("127.0.0.1:0")
+	require.NoError(t, err)
+	require.NoError(t, listener.Close())
+
+	for _, address := range []string{":0", "0.0.0.0:0", "[::]:0", "192.0.2.1:0", "zemu.local:0", "invalid"} {
+		_, err := server.Listen(address)
+		assert.ErrorIs(t, err, ErrInsecureZemuServer, address)
+	}
+
+	for _, clientAuth := range []tls.ClientAuthType{tls.NoClientCert, tls.RequestClientCert, tls.RequireAnyClientCert, tls.VerifyClientCertIfGiven} {
+		_, err := NewZemuServer(newVirtualApp(), WithZemuServerTLS(&tls.Config{ClientAuth: clientAuth})).Listen(":0")
+		assert.ErrorIs(t, err, ErrInsecureZemuServer, clientAuth)
+	}
+
+	listener, err = NewZemuServer(newVirtualApp(), WithZemuServerTLS(&tls.Config{ClientAuth: tls.RequireAndVerifyClientCert})).Listen(":0")
+	require.NoError(t, err)
+	require.NoError(t, listener.Close())
+}